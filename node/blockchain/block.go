@@ -1,12 +1,12 @@
 package blockchain
 
 import (
-    "bytes"
-    "crypto/sha256"
-    "encoding/binary"
-    "encoding/hex"
+    "encoding/json"
     "fmt"
     "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
 )
 
 // Block represents a block in the blockchain
@@ -16,15 +16,7 @@ type Block struct {
 }
 
 // BlockHeader contains the block metadata
-type BlockHeader struct {
-    Version        uint32    `json:"version"`
-    PrevBlockHash  string    `json:"prev_block_hash"`
-    MerkleRoot     string    `json:"merkle_root"`
-    Timestamp      time.Time `json:"timestamp"`
-    Bits           uint32    `json:"bits"`
-    Nonce          uint32    `json:"nonce"`
-    Height         uint64    `json:"height"`
-}
+type BlockHeader = consensus.Header
 
 // NewBlock creates a new block
 func NewBlock(transactions []*Transaction, prevBlockHash string, height uint64) *Block {
@@ -32,20 +24,22 @@ func NewBlock(transactions []*Transaction, prevBlockHash string, height uint64)
         Header: BlockHeader{
             Version:       1,
             PrevBlockHash: prevBlockHash,
-            Timestamp:     time.Now(),
+            Timestamp:     time.Now().Truncate(time.Second),
             Height:        height,
         },
         Transactions: transactions,
     }
     
-    // Calculate merkle root
-    block.Header.MerkleRoot = block.CalculateMerkleRoot()
+    // Calculate merkle root, transactions that cannot be encoded leave it empty
+    // and the block fails validation
+    block.Header.MerkleRoot, _ = block.CalculateMerkleRoot()
     
     return block
 }
 
-// GenesisBlock creates the genesis block
-func GenesisBlock() *Block {
+// GenesisBlock creates the genesis block of a network. Its contents are fixed
+// by the network parameters so that every node creates the same block.
+func GenesisBlock(params *chainparams.Params) *Block {
     // Create genesis transaction
     genesisTx := &Transaction{
         ID:        "genesis",
@@ -53,148 +47,72 @@ func GenesisBlock() *Block {
         Name:      "Genesis",
         Surname:   "Block",
         InquiryID: "genesis",
-        Datetime:  time.Now(),
+        Datetime:  params.GenesisTime,
         Signature: "",
     }
     
-    return NewBlock([]*Transaction{genesisTx}, "0", 0)
+    block := NewBlock([]*Transaction{genesisTx}, "0", 0)
+    block.Header.Timestamp = params.GenesisTime
+    block.Header.Bits = params.GenesisBits
+    block.Header.StateRoot = EmptyStateRoot
+    
+    return block
 }
 
 // Hash calculates the hash of the block header
 func (b *Block) Hash() string {
-    var buf bytes.Buffer
-    
-    // Write header fields in order
-    binary.Write(&buf, binary.BigEndian, b.Header.Version)
-    buf.WriteString(b.Header.PrevBlockHash)
-    buf.WriteString(b.Header.MerkleRoot)
-    binary.Write(&buf, binary.BigEndian, b.Header.Timestamp.Unix())
-    binary.Write(&buf, binary.BigEndian, b.Header.Bits)
-    binary.Write(&buf, binary.BigEndian, b.Header.Nonce)
-    
-    hash := sha256.Sum256(buf.Bytes())
-    return hex.EncodeToString(hash[:])
+    return b.Header.Hash()
 }
 
-// CalculateMerkleRoot calculates the merkle root of transactions
-func (b *Block) CalculateMerkleRoot() string {
-    if len(b.Transactions) == 0 {
-        return ""
-    }
-    
-    var hashes [][]byte
-    
-    // Get transaction hashes
-    for _, tx := range b.Transactions {
-        hash, _ := hex.DecodeString(tx.Hash())
-        hashes = append(hashes, hash)
-    }
-    
-    // Build merkle tree
-    for len(hashes) > 1 {
-        var newHashes [][]byte
-        
-        for i := 0; i < len(hashes); i += 2 {
-            var combined []byte
-            combined = append(combined, hashes[i]...)
-            
-            if i+1 < len(hashes) {
-                combined = append(combined, hashes[i+1]...)
-            } else {
-                // If odd number, duplicate last hash
-                combined = append(combined, hashes[i]...)
-            }
-            
-            hash := sha256.Sum256(combined)
-            newHashes = append(newHashes, hash[:])
-        }
-        
-        hashes = newHashes
-    }
-    
-    return hex.EncodeToString(hashes[0])
-}
-
-// Validate validates the block
+// Validate checks the structure of the block and its transactions. Rules that
+// depend on the block's position in the chain are checked when it is added.
 func (b *Block) Validate() error {
-    // Check if block has transactions
-    if len(b.Transactions) == 0 {
-        return fmt.Errorf("block has no transactions")
-    }
-    
     // Validate merkle root
-    calculatedRoot := b.CalculateMerkleRoot()
-    if calculatedRoot != b.Header.MerkleRoot {
-        return fmt.Errorf("invalid merkle root")
+    if err := b.checkMerkleRoot(); err != nil {
+        return err
     }
     
-    // Validate each transaction
+    // Validate each transaction, a transaction may appear only once
+    seen := make(map[string]bool, len(b.Transactions))
     for _, tx := range b.Transactions {
         if err := tx.Validate(); err != nil {
             return fmt.Errorf("invalid transaction %s: %w", tx.ID, err)
         }
+        
+        if seen[tx.ID] {
+            return fmt.Errorf("duplicate transaction %s", tx.ID)
+        }
+        seen[tx.ID] = true
+    }
+    
+    // Every field must fit the limits of the binary encoding
+    if _, err := b.Serialize(); err != nil {
+        return fmt.Errorf("block cannot be encoded: %w", err)
     }
     
     return nil
 }
 
-// Serialize serializes the block to bytes
+// Serialize encodes the block in the canonical binary format used for storage and peers
 func (b *Block) Serialize() ([]byte, error) {
-    var buf bytes.Buffer
-    
-    // Magic value
-    binary.Write(&buf, binary.BigEndian, uint32(0xD9B4BEF9))
-    
-    // Create temporary buffer for block data
-    var blockBuf bytes.Buffer
-    
-    // Header
-    binary.Write(&blockBuf, binary.BigEndian, b.Header.Version)
-    
-    // Previous block hash (32 bytes)
-    prevHash, _ := hex.DecodeString(b.Header.PrevBlockHash)
-    if len(prevHash) < 32 {
-        prevHash = append(prevHash, make([]byte, 32-len(prevHash))...)
-    }
-    blockBuf.Write(prevHash[:32])
-    
-    // Merkle root (32 bytes)
-    merkleRoot, _ := hex.DecodeString(b.Header.MerkleRoot)
-    if len(merkleRoot) < 32 {
-        merkleRoot = append(merkleRoot, make([]byte, 32-len(merkleRoot))...)
-    }
-    blockBuf.Write(merkleRoot[:32])
-    
-    // Timestamp
-    binary.Write(&blockBuf, binary.BigEndian, b.Header.Timestamp.Unix())
-    
-    // Bits and nonce
-    binary.Write(&blockBuf, binary.BigEndian, b.Header.Bits)
-    binary.Write(&blockBuf, binary.BigEndian, b.Header.Nonce)
+    e := &encoder{}
+    e.block(b)
     
-    // Height
-    binary.Write(&blockBuf, binary.BigEndian, b.Header.Height)
-    
-    // Transaction count
-    binary.Write(&blockBuf, binary.BigEndian, uint32(len(b.Transactions)))
-    
-    // Transactions
-    for _, tx := range b.Transactions {
-        txBytes, err := tx.Serialize()
-        if err != nil {
-            return nil, err
-        }
-        blockBuf.Write(txBytes)
+    return e.buf, e.err
+}
+
+// ToJSON converts the block to JSON for the REST API
+func (b *Block) ToJSON() ([]byte, error) {
+    return json.Marshal(b)
+}
+
+// BlockFromJSON creates a block from JSON
+func BlockFromJSON(data []byte) (*Block, error) {
+    var block Block
+    if err := json.Unmarshal(data, &block); err != nil {
+        return nil, err
     }
-    
-    // Write block size
-    blockData := blockBuf.Bytes()
-    binary.Write(&buf, binary.BigEndian, uint32(len(blockData)))
-    
-    // Write block data
-    buf.Write(blockData)
-    
-    return buf.Bytes(), nil
+    return &block, nil
 }
 
 // GetTransactionByID finds a transaction by ID
@@ -2,143 +2,424 @@ package blockchain
 
 import (
     "context"
+    "errors"
     "fmt"
+    "sort"
     "sync"
     "time"
     
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/config"
     "github.com/CertificationAgencyBlockchain/node/consensus"
+    "github.com/CertificationAgencyBlockchain/node/mempool"
     "github.com/CertificationAgencyBlockchain/node/storage"
     "github.com/CertificationAgencyBlockchain/node/utils"
 )
 
+// ErrBlockKnown is returned when a block is already part of the block tree
+var ErrBlockKnown = errors.New("block already known")
+
+// ErrOnDemandMiningDisabled is returned when generating blocks on a network that mines in the background
+var ErrOnDemandMiningDisabled = errors.New("block generation is only available on networks with on-demand mining")
+
 // Blockchain represents the blockchain
 type Blockchain struct {
     mu              sync.RWMutex
     blocks          []*Block
     currentHeight   uint64
+    index           map[string]*blockNode
+    tip             *blockNode
+    orphans         *orphanPool
+    sigCache        *sigCache
+    clock           *medianTime
+    engine          consensus.Engine
+    params          *chainparams.Params
+    config          *config.Config
     db              *storage.Database
     logger          *utils.Logger
     
     // Mining
-    miningPool      []*Transaction
+    pool            *mempool.Pool
     miningMu        sync.Mutex
-    difficulty      uint32
+    produceMu       sync.Mutex
     miningEnabled   bool
-    
-    // Consensus
-    pow             *consensus.ProofOfWork
+    onBlockMined    func(*Block)
+    sealParent      string
+    cancelSeal      context.CancelFunc
 }
 
 // NewBlockchain creates a new blockchain
-func NewBlockchain(db *storage.Database, logger *utils.Logger) (*Blockchain, error) {
+func NewBlockchain(cfg *config.Config, db *storage.Database, logger *utils.Logger) (*Blockchain, error) {
+    params, err := chainparams.ForNetwork(cfg.Network.Chain)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Every node of the network must create exactly the same genesis block
+    genesis := GenesisBlock(params)
+    if hash := genesis.Hash(); hash != params.GenesisHash {
+        return nil, fmt.Errorf("%s genesis block hashes to %s, expected %s", params.Name, hash, params.GenesisHash)
+    }
+    
+    engine, err := NewEngine(cfg)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create consensus engine: %w", err)
+    }
+    
+    // Pending transactions are persisted so they survive restarts
+    pool, err := mempool.New(db, cfg.Mempool.MaxSize, cfg.Security.MaxInquiryAge, decodePoolEntry, logger)
+    if err != nil {
+        return nil, err
+    }
+    
     bc := &Blockchain{
         blocks:        make([]*Block, 0),
+        index:         make(map[string]*blockNode),
+        orphans:       newOrphanPool(),
+        sigCache:      newSigCache(),
+        clock:         newMedianTime(),
+        engine:        engine,
+        params:        params,
+        config:        cfg,
         db:            db,
         logger:        logger,
-        miningPool:    make([]*Transaction, 0),
-        difficulty:    16, // Initial difficulty
+        pool:          pool,
         miningEnabled: false,
     }
     
-    // Initialize proof of work
-    bc.pow = consensus.NewProofOfWork(bc.difficulty)
-    
     // Load blockchain from database
     if err := bc.loadFromDB(); err != nil {
-        // If no blockchain exists, create genesis block
-        bc.logger.Info("Creating new blockchain with genesis block")
-        genesis := GenesisBlock()
+        return nil, fmt.Errorf("failed to load blockchain: %w", err)
+    }
+    
+    // If no blockchain exists, create genesis block
+    if len(bc.blocks) == 0 {
+        bc.logger.Info("Creating new %s blockchain with genesis block %s", params.Name, params.GenesisHash)
         if err := bc.AddBlock(genesis); err != nil {
             return nil, fmt.Errorf("failed to add genesis block: %w", err)
         }
     }
     
+    // Refuse to run on a database created for another network
+    if stored := bc.blocks[0].Hash(); stored != params.GenesisHash {
+        return nil, fmt.Errorf("stored genesis block %s does not match the %s genesis block %s",
+            stored, params.Name, params.GenesisHash)
+    }
+    
+    bc.pruneMinedPoolEntries()
+    
     return bc, nil
 }
 
-// loadFromDB loads the blockchain from the database
+// loadFromDB loads the blockchain from the database and resumes at the stored tip
 func (bc *Blockchain) loadFromDB() error {
     // Load blocks from database
-    blocks, err := bc.db.GetAllBlocks()
+    data, err := bc.db.GetAllBlocks()
     if err != nil {
         return err
     }
     
-    if len(blocks) == 0 {
-        return fmt.Errorf("no blocks found")
+    if len(data) == 0 {
+        return nil
+    }
+    
+    blocks := make([]*Block, 0, len(data))
+    for height, raw := range data {
+        block, err := DeserializeBlock(raw)
+        if err != nil {
+            return fmt.Errorf("failed to decode block %d: %w", height, err)
+        }
+        blocks = append(blocks, block)
+    }
+    
+    // Check the whole stored chain before trusting it
+    tip, err := bc.verifyChain(blocks)
+    if err != nil {
+        return fmt.Errorf("stored chain is invalid: %w", err)
+    }
+    
+    for node := tip; node != nil; node = node.parent {
+        bc.index[node.hash] = node
     }
     
     bc.blocks = blocks
-    bc.currentHeight = uint64(len(blocks) - 1)
+    bc.tip = tip
+    bc.currentHeight = tip.height
+    bc.updateAuthorities()
+    
+    // The stored chain work must agree with the recomputed one
+    storedWork, err := bc.db.GetChainWork(tip.hash)
+    if err != nil {
+        return fmt.Errorf("failed to read chain work: %w", err)
+    }
+    if storedWork == nil || storedWork.Cmp(tip.chainWork) != 0 {
+        bc.logger.Warn("Stored chain work for tip %s does not match the recomputed value", tip.hash)
+    }
     
-    bc.logger.Info("Loaded %d blocks from database", len(blocks))
+    // Restore side chains so fork choice survives restarts
+    if err := bc.loadSideChains(); err != nil {
+        return fmt.Errorf("failed to load side chains: %w", err)
+    }
+    
+    bc.logger.Info("Loaded %d blocks from database, resuming at height %d", len(blocks), bc.currentHeight)
     return nil
 }
 
-// AddBlock adds a new block to the blockchain
+// loadSideChains indexes stored blocks that are not part of the main chain
+func (bc *Blockchain) loadSideChains() error {
+    data, err := bc.db.GetStoredBlocks()
+    if err != nil {
+        return err
+    }
+    
+    var side []*Block
+    for _, raw := range data {
+        block, err := DeserializeBlock(raw)
+        if err != nil {
+            return fmt.Errorf("failed to decode block: %w", err)
+        }
+        if _, ok := bc.index[block.Hash()]; !ok {
+            side = append(side, block)
+        }
+    }
+    
+    // Parents always have a lower height than their children
+    sort.Slice(side, func(i, j int) bool {
+        return side[i].Header.Height < side[j].Header.Height
+    })
+    
+    for _, block := range side {
+        parent, ok := bc.index[block.Header.PrevBlockHash]
+        if !ok {
+            bc.logger.Warn("Skipping stored block %s with unknown parent", block.Hash())
+            continue
+        }
+        node, err := bc.newBlockNode(block, parent)
+        if err != nil {
+            bc.logger.Warn("Skipping stored block %s: %v", block.Hash(), err)
+            continue
+        }
+        bc.index[node.hash] = node
+    }
+    
+    if len(side) > 0 {
+        bc.logger.Info("Loaded %d side chain blocks from database", len(side))
+    }
+    
+    return nil
+}
+
+// verifyChain checks the linkage, merkle roots, difficulty, seals and state
+// roots of a stored chain and returns the node of its last block
+func (bc *Blockchain) verifyChain(blocks []*Block) (*blockNode, error) {
+    var parent *blockNode
+    
+    for i, block := range blocks {
+        if block.Header.Height != uint64(i) {
+            return nil, fmt.Errorf("block at position %d has height %d", i, block.Header.Height)
+        }
+        
+        if err := block.checkMerkleRoot(); err != nil {
+            return nil, fmt.Errorf("block %d: %w", i, err)
+        }
+        
+        // The genesis block is not mined
+        if i > 0 {
+            if block.Header.PrevBlockHash != parent.hash {
+                return nil, fmt.Errorf("block %d does not link to block %d", i, i-1)
+            }
+            
+            if err := bc.engine.VerifySeal(parent, &block.Header); err != nil {
+                return nil, fmt.Errorf("block %d has an invalid seal: %w", i, err)
+            }
+            
+            if mtp := medianTimePast(parent, &parent.block.Header); block.Header.Timestamp.Unix() <= mtp.Unix() {
+                return nil, fmt.Errorf("block %d is not after the median time past", i)
+            }
+            
+            if err := bc.checkBlockContext(block); err != nil {
+                return nil, fmt.Errorf("block %d: %w", i, err)
+            }
+        }
+        
+        node, err := bc.newBlockNode(block, parent)
+        if err != nil {
+            return nil, fmt.Errorf("block %d: %w", i, err)
+        }
+        if err := node.checkStateRoot(); err != nil {
+            return nil, fmt.Errorf("block %d: %w", i, err)
+        }
+        parent = node
+    }
+    
+    return parent, nil
+}
+
+// AddBlock adds a new block to the block tree and switches to the heaviest chain.
+// Blocks whose parent is unknown are kept as orphans and ErrOrphanBlock is returned.
 func (bc *Blockchain) AddBlock(block *Block) error {
     bc.mu.Lock()
     defer bc.mu.Unlock()
     
+    if err := bc.addBlock(block); err != nil {
+        return err
+    }
+    
+    // Connect any orphans that were waiting for this block
+    bc.processOrphans(block.Hash())
+    
+    return nil
+}
+
+// addBlock adds a block to the block tree, the caller must hold bc.mu
+func (bc *Blockchain) addBlock(block *Block) error {
+    hash := block.Hash()
+    if _, ok := bc.index[hash]; ok {
+        return ErrBlockKnown
+    }
+    
+    if bc.orphans.has(hash) {
+        return ErrOrphanBlock
+    }
+    
+    // The first block of an empty chain is the genesis block, whose transactions are not signed
+    if bc.tip == nil {
+        if block.Header.Height != 0 {
+            return fmt.Errorf("first block must be the genesis block")
+        }
+        if err := block.checkMerkleRoot(); err != nil {
+            return err
+        }
+        node, err := bc.newBlockNode(block, nil)
+        if err != nil {
+            return err
+        }
+        if err := node.checkStateRoot(); err != nil {
+            return fmt.Errorf("invalid block: %w", err)
+        }
+        return bc.connectBlock(node)
+    }
+    
     // Validate block
     if err := block.Validate(); err != nil {
         return fmt.Errorf("invalid block: %w", err)
     }
     
-    // Check previous block hash
-    if len(bc.blocks) > 0 {
-        lastBlock := bc.blocks[len(bc.blocks)-1]
-        if block.Header.PrevBlockHash != lastBlock.Hash() {
-            return fmt.Errorf("invalid previous block hash")
-        }
-        
-        // Check height
-        if block.Header.Height != lastBlock.Header.Height+1 {
-            return fmt.Errorf("invalid block height")
-        }
+    // Keep blocks with an unknown parent until the parent arrives
+    parent, ok := bc.index[block.Header.PrevBlockHash]
+    if !ok {
+        return bc.addOrphan(block, hash)
     }
     
-    // Verify proof of work
-    if !bc.pow.Validate(block) {
-        return fmt.Errorf("invalid proof of work")
+    // Check height
+    if block.Header.Height != parent.height+1 {
+        return fmt.Errorf("invalid block height")
     }
     
-    // Add block to chain
-    bc.blocks = append(bc.blocks, block)
-    bc.currentHeight = block.Header.Height
-    
-    // Save to database
-    if err := bc.db.SaveBlock(block); err != nil {
-        // Rollback
-        bc.blocks = bc.blocks[:len(bc.blocks)-1]
-        bc.currentHeight--
+    // Check the timestamp against the median time past and the adjusted time
+    if err := bc.checkTimestamp(parent, &block.Header); err != nil {
+        return fmt.Errorf("invalid timestamp: %w", err)
+    }
+    
+    // Verify the consensus seal and difficulty
+    if err := bc.engine.VerifySeal(parent, &block.Header); err != nil {
+        return fmt.Errorf("invalid seal: %w", err)
+    }
+    
+    // Check the block size and its transactions against the block timestamp
+    if err := bc.checkBlockContext(block); err != nil {
+        return fmt.Errorf("invalid block: %w", err)
+    }
+    
+    // Verify every transaction signature
+    if err := bc.verifyBlockSignatures(block); err != nil {
+        return fmt.Errorf("invalid block: %w", err)
+    }
+    
+    // The header must commit to the certification state after the block
+    node, err := bc.newBlockNode(block, parent)
+    if err != nil {
+        return fmt.Errorf("invalid block: %w", err)
+    }
+    if err := node.checkStateRoot(); err != nil {
+        return fmt.Errorf("invalid block: %w", err)
+    }
+    
+    // Blocks that extend the tip are connected directly
+    if parent == bc.tip {
+        return bc.connectBlock(node)
+    }
+    
+    // Everything else is kept as a side chain block
+    data, err := block.Serialize()
+    if err != nil {
+        return fmt.Errorf("failed to encode block: %w", err)
+    }
+    
+    if err := bc.db.StoreBlock(hash, data, node.chainWork); err != nil {
         return fmt.Errorf("failed to save block: %w", err)
     }
+    bc.index[hash] = node
+    
+    // Switch chains when the side chain has more cumulative work
+    if node.chainWork.Cmp(bc.tip.chainWork) <= 0 {
+        bc.logger.Info("Added side chain block %d with hash %s", block.Header.Height, hash)
+        return nil
+    }
+    
+    return bc.reorganize(node)
+}
+
+// checkBlockContext checks the encoded block size and that every transaction was fresh at the block timestamp
+func (bc *Blockchain) checkBlockContext(block *Block) error {
+    data, err := block.Serialize()
+    if err != nil {
+        return err
+    }
+    if len(data) > bc.config.Blockchain.MaxBlockSize {
+        return fmt.Errorf("block is %d bytes, the limit is %d", len(data), bc.config.Blockchain.MaxBlockSize)
+    }
     
-    // Update database with certifications
     for _, tx := range block.Transactions {
-        cert := &storage.Certification{
-            PublicKey: tx.PublicKey,
-            Name:      tx.Name,
-            Surname:   tx.Surname,
-            InquiryID: tx.InquiryID,
-            Datetime:  tx.Datetime,
-            BlockHash: block.Hash(),
-            Height:    block.Header.Height,
-        }
-        
-        if err := bc.db.SaveCertification(cert); err != nil {
-            bc.logger.Error("Failed to save certification: %v", err)
+        if err := tx.ValidateInBlock(block.Header.Timestamp, bc.config.Security.MaxInquiryAge); err != nil {
+            return fmt.Errorf("transaction %s: %w", tx.ID, err)
         }
     }
     
-    // Remove mined transactions from pool
-    bc.removeMinedTransactions(block.Transactions)
-    
-    bc.logger.Info("Added block %d with hash %s", block.Header.Height, block.Hash())
     return nil
 }
 
+// addOrphan checks a block that cannot be connected yet and adds it to the orphan pool
+func (bc *Blockchain) addOrphan(block *Block, hash string) error {
+    if block.Header.Height > bc.tip.height+maxOrphanDepth {
+        return fmt.Errorf("orphan block %d is too far ahead of the chain tip", block.Header.Height)
+    }
+    
+    if err := bc.checkFutureTimestamp(&block.Header); err != nil {
+        return fmt.Errorf("invalid timestamp: %w", err)
+    }
+    
+    // Only keep orphans that carry a valid seal
+    if err := bc.engine.VerifySeal(nil, &block.Header); err != nil {
+        return fmt.Errorf("invalid seal: %w", err)
+    }
+    
+    bc.orphans.add(block, hash)
+    bc.logger.Info("Added orphan block %d with hash %s, missing parent %s",
+        block.Header.Height, hash, block.Header.PrevBlockHash)
+    
+    return ErrOrphanBlock
+}
+
+// HaveBlock checks if a block is in the block tree or the orphan pool
+func (bc *Blockchain) HaveBlock(hash string) bool {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    _, ok := bc.index[hash]
+    return ok || bc.orphans.has(hash)
+}
+
 // GetBlock gets a block by height
 func (bc *Blockchain) GetBlock(height uint64) (*Block, error) {
     bc.mu.RLock()
@@ -151,15 +432,89 @@ func (bc *Blockchain) GetBlock(height uint64) (*Block, error) {
     return bc.blocks[height], nil
 }
 
+// GetBlocks gets up to count main chain blocks starting at a height
+func (bc *Blockchain) GetBlocks(from uint64, count int) []*Block {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    blocks := make([]*Block, 0, count)
+    for h := from; h < uint64(len(bc.blocks)) && len(blocks) < count; h++ {
+        blocks = append(blocks, bc.blocks[h])
+    }
+    
+    return blocks
+}
+
+// GetHeaders gets up to count main chain headers starting at a height
+func (bc *Blockchain) GetHeaders(from uint64, count int) []BlockHeader {
+    blocks := bc.GetBlocks(from, count)
+    
+    headers := make([]BlockHeader, 0, len(blocks))
+    for _, block := range blocks {
+        headers = append(headers, block.Header)
+    }
+    
+    return headers
+}
+
+// CheckHeaders validates that headers form a correctly sealed chain on top of a
+// known block. Only headers from index checked on are validated, the ones before
+// must have been checked by an earlier call.
+func (bc *Blockchain) CheckHeaders(headers []BlockHeader, checked int) error {
+    if len(headers) == 0 || checked >= len(headers) {
+        return nil
+    }
+    
+    bc.mu.RLock()
+    base, ok := bc.index[headers[0].PrevBlockHash]
+    bc.mu.RUnlock()
+    
+    if !ok {
+        return fmt.Errorf("headers do not connect to a known block")
+    }
+    
+    chain := &headerChain{base: base, headers: headers}
+    
+    prevHash := base.hash
+    prevHeight := base.height
+    if checked > 0 {
+        prevHash = headers[checked-1].Hash()
+        prevHeight = headers[checked-1].Height
+    }
+    
+    for i := checked; i < len(headers); i++ {
+        header := &headers[i]
+        
+        if header.PrevBlockHash != prevHash {
+            return fmt.Errorf("header %d does not link to its predecessor", header.Height)
+        }
+        
+        if header.Height != prevHeight+1 {
+            return fmt.Errorf("header %d has invalid height", header.Height)
+        }
+        
+        if err := bc.checkTimestamp(chain, header); err != nil {
+            return fmt.Errorf("header %d has an invalid timestamp: %w", header.Height, err)
+        }
+        
+        if err := bc.engine.VerifySeal(chain, header); err != nil {
+            return fmt.Errorf("header %d has an invalid seal: %w", header.Height, err)
+        }
+        
+        prevHash = header.Hash()
+        prevHeight = header.Height
+    }
+    
+    return nil
+}
+
 // GetBlockByHash gets a block by hash
 func (bc *Blockchain) GetBlockByHash(hash string) (*Block, error) {
     bc.mu.RLock()
     defer bc.mu.RUnlock()
     
-    for _, block := range bc.blocks {
-        if block.Hash() == hash {
-            return block, nil
-        }
+    if node, ok := bc.index[hash]; ok {
+        return node.block, nil
     }
     
     return nil, fmt.Errorf("block not found")
@@ -195,8 +550,13 @@ func (bc *Blockchain) AddTransaction(tx *Transaction) error {
         return err
     }
     
+    // Only recent transactions are admitted to the pool
+    if err := tx.ValidateFreshness(bc.AdjustedTime(), bc.config.Security.MaxInquiryAge); err != nil {
+        return err
+    }
+    
     // Verify signature
-    if err := tx.VerifySignature(); err != nil {
+    if err := bc.verifySignature(tx); err != nil {
         return fmt.Errorf("invalid signature: %w", err)
     }
     
@@ -205,36 +565,105 @@ func (bc *Blockchain) AddTransaction(tx *Transaction) error {
         return fmt.Errorf("inquiry ID already exists")
     }
     
-    // Check if already in pool
-    for _, poolTx := range bc.miningPool {
-        if poolTx.ID == tx.ID {
-            return fmt.Errorf("transaction already in pool")
-        }
+    // Add to pool, which rejects duplicates by ID, inquiry ID and public key
+    entry, err := newPoolEntry(tx)
+    if err != nil {
+        return err
+    }
+    if err := bc.pool.Add(entry); err != nil {
+        return err
     }
-    
-    // Add to pool
-    bc.miningPool = append(bc.miningPool, tx)
     bc.logger.Info("Added transaction %s to mining pool", tx.ID)
     
     return nil
 }
 
-// GetMiningPool returns transactions in the mining pool
+// GetMiningPool returns transactions in the mining pool in arrival order
 func (bc *Blockchain) GetMiningPool() []*Transaction {
-    bc.miningMu.Lock()
-    defer bc.miningMu.Unlock()
+    entries := bc.pool.Entries()
+    
+    pool := make([]*Transaction, 0, len(entries))
+    for _, entry := range entries {
+        tx, err := DeserializeTransaction(entry.Data)
+        if err != nil {
+            bc.logger.Warn("Skipping pool transaction %s: %v", entry.ID, err)
+            continue
+        }
+        pool = append(pool, tx)
+    }
     
-    pool := make([]*Transaction, len(bc.miningPool))
-    copy(pool, bc.miningPool)
     return pool
 }
 
-// StartMining starts the mining process
+// Mempool returns the pool of transactions waiting to be mined
+func (bc *Blockchain) Mempool() *mempool.Pool {
+    return bc.pool
+}
+
+// newPoolEntry encodes a transaction for the mempool
+func newPoolEntry(tx *Transaction) (*mempool.Entry, error) {
+    data, err := tx.Serialize()
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode transaction: %w", err)
+    }
+    
+    return &mempool.Entry{
+        ID:        tx.ID,
+        InquiryID: tx.InquiryID,
+        PublicKey: tx.PublicKey,
+        Datetime:  tx.Datetime,
+        Data:      data,
+    }, nil
+}
+
+// decodePoolEntry rebuilds a mempool entry from a stored transaction encoding
+func decodePoolEntry(data []byte) (*mempool.Entry, error) {
+    tx, err := DeserializeTransaction(data)
+    if err != nil {
+        return nil, err
+    }
+    
+    return newPoolEntry(tx)
+}
+
+// pruneMinedPoolEntries drops restored pool transactions whose inquiry was
+// mined before the node stopped
+func (bc *Blockchain) pruneMinedPoolEntries() {
+    mined := make(map[string]bool)
+    for _, block := range bc.blocks {
+        for _, tx := range block.Transactions {
+            mined[tx.InquiryID] = true
+        }
+    }
+    
+    var stale []*mempool.Entry
+    for _, entry := range bc.pool.Entries() {
+        if mined[entry.InquiryID] {
+            stale = append(stale, entry)
+        }
+    }
+    
+    if err := bc.pool.Remove(stale); err != nil {
+        bc.logger.Error("Failed to prune mined pool transactions: %v", err)
+    }
+    
+    if stats := bc.pool.Stats(); stats.Count > 0 {
+        bc.logger.Info("Restored %d pending transactions (%d bytes)", stats.Count, stats.Size)
+    }
+}
+
+// StartMining starts the mining process, producing blocks as the production policy allows
 func (bc *Blockchain) StartMining(ctx context.Context) {
+    if bc.params.OnDemandMining {
+        bc.logger.Info("Background mining is off on %s, blocks are only generated on request", bc.params.Name)
+        return
+    }
+    
     bc.miningEnabled = true
-    bc.logger.Info("Mining started")
+    bc.logger.Info("Mining started (min batch %d, max wait %s, empty blocks %t)",
+        bc.config.Mining.MinBatchSize, bc.config.Mining.MaxWait, bc.config.Mining.MineEmptyBlocks)
     
-    ticker := time.NewTicker(30 * time.Second)
+    ticker := time.NewTicker(policyCheckInterval)
     defer ticker.Stop()
     
     for {
@@ -242,95 +671,137 @@ func (bc *Blockchain) StartMining(ctx context.Context) {
         case <-ctx.Done():
             bc.logger.Info("Mining stopped")
             return
-        case <-ticker.C:
-            if len(bc.GetMiningPool()) > 0 {
-                bc.mineBlock()
+        case now := <-ticker.C:
+            if bc.miningEnabled && bc.shouldProduceBlock(now) {
+                bc.mineBlock(ctx)
             }
         }
     }
 }
 
+// SetBlockMinedHandler sets the function called for every block mined by this node
+func (bc *Blockchain) SetBlockMinedHandler(handler func(*Block)) {
+    bc.onBlockMined = handler
+}
+
 // StopMining stops the mining process
 func (bc *Blockchain) StopMining() {
     bc.miningEnabled = false
 }
 
 // mineBlock mines a new block
-func (bc *Blockchain) mineBlock() {
-    bc.miningMu.Lock()
+func (bc *Blockchain) mineBlock(ctx context.Context) {
+    if _, err := bc.produceBlock(ctx, bc.config.Mining.MineEmptyBlocks); err != nil {
+        if errors.Is(err, context.Canceled) && ctx.Err() == nil {
+            bc.logger.Info("Stopped mining, the chain tip changed")
+        } else {
+            bc.logger.Warn("Mining failed: %v", err)
+        }
+    }
+}
+
+// GenerateBlocks mines n blocks right away on networks that produce blocks on demand.
+// Pending transactions go into the first blocks, the remaining blocks are empty.
+func (bc *Blockchain) GenerateBlocks(ctx context.Context, n int) ([]*Block, error) {
+    if !bc.params.OnDemandMining {
+        return nil, ErrOnDemandMiningDisabled
+    }
     
-    // Get transactions to mine (max 1000)
-    var transactions []*Transaction
-    maxTx := 1000
-    if len(bc.miningPool) < maxTx {
-        transactions = make([]*Transaction, len(bc.miningPool))
-        copy(transactions, bc.miningPool)
-    } else {
-        transactions = make([]*Transaction, maxTx)
-        copy(transactions, bc.miningPool[:maxTx])
+    blocks := make([]*Block, 0, n)
+    for i := 0; i < n; i++ {
+        block, err := bc.produceBlock(ctx, true)
+        if err != nil {
+            return blocks, err
+        }
+        blocks = append(blocks, block)
     }
     
-    bc.miningMu.Unlock()
+    return blocks, nil
+}
+
+// produceBlock builds a block from the pool, seals it and adds it to the chain. It
+// returns no block when the pool is empty and empty blocks are not allowed.
+func (bc *Blockchain) produceBlock(ctx context.Context, allowEmpty bool) (*Block, error) {
+    bc.produceMu.Lock()
+    defer bc.produceMu.Unlock()
     
-    if len(transactions) == 0 {
-        return
+    newBlock, parent := bc.buildBlockTemplate()
+    
+    if len(newBlock.Transactions) == 0 && !allowEmpty {
+        return nil, nil
     }
     
-    // Create new block
-    latestBlock := bc.GetLatestBlock()
-    newBlock := NewBlock(transactions, latestBlock.Hash(), latestBlock.Header.Height+1)
-    newBlock.Header.Bits = bc.difficulty
+    bc.logger.Info("Mining block %d with %d transactions at target %08x",
+        newBlock.Header.Height, len(newBlock.Transactions), newBlock.Header.Bits)
     
-    bc.logger.Info("Mining block %d with %d transactions", newBlock.Header.Height, len(transactions))
+    // Seal the block until it is found or the tip changes
+    sealCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    bc.startSeal(parent.hash, cancel)
+    defer bc.startSeal("", nil)
     
-    // Mine the block
-    if bc.pow.Mine(newBlock) {
-        // Add block to chain
-        if err := bc.AddBlock(newBlock); err != nil {
-            bc.logger.Error("Failed to add mined block: %v", err)
-        } else {
-            bc.logger.Info("Successfully mined block %d", newBlock.Header.Height)
-            
-            // Adjust difficulty if needed
-            if newBlock.Header.Height%2016 == 0 {
-                bc.adjustDifficulty()
-            }
-        }
+    if err := bc.engine.Seal(sealCtx, parent, &newBlock.Header); err != nil {
+        return nil, fmt.Errorf("failed to seal block %d: %w", newBlock.Header.Height, err)
+    }
+    
+    // Add block to chain
+    if err := bc.AddBlock(newBlock); err != nil {
+        return nil, fmt.Errorf("failed to add mined block: %w", err)
     }
+    
+    bc.logger.Info("Successfully mined block %d (%.0f H/s)", newBlock.Header.Height, bc.Hashrate())
+    
+    // Announce the block to the network
+    if bc.onBlockMined != nil {
+        bc.onBlockMined(newBlock)
+    }
+    
+    return newBlock, nil
 }
 
-// adjustDifficulty adjusts the mining difficulty
-func (bc *Blockchain) adjustDifficulty() {
-    // Simple difficulty adjustment
-    // In production, this should be based on block time
-    bc.mu.Lock()
-    defer bc.mu.Unlock()
+// startSeal records the parent of the block being sealed and how to abort it
+func (bc *Blockchain) startSeal(parent string, cancel context.CancelFunc) {
+    bc.miningMu.Lock()
+    defer bc.miningMu.Unlock()
     
-    // For now, just log
-    bc.logger.Info("Difficulty adjustment check at height %d", bc.currentHeight)
+    bc.sealParent = parent
+    bc.cancelSeal = cancel
 }
 
-// removeMinedTransactions removes mined transactions from the pool
-func (bc *Blockchain) removeMinedTransactions(minedTxs []*Transaction) {
+// abortStaleSeal cancels sealing when the block being sealed no longer extends the tip
+func (bc *Blockchain) abortStaleSeal(tip string) {
     bc.miningMu.Lock()
     defer bc.miningMu.Unlock()
     
-    newPool := make([]*Transaction, 0)
+    if bc.cancelSeal != nil && bc.sealParent != tip {
+        bc.cancelSeal()
+    }
+}
+
+// Hashrate returns the hashes per second of the miner, zero when the engine does not hash
+func (bc *Blockchain) Hashrate() float64 {
+    if hasher, ok := bc.engine.(interface{ Hashrate() float64 }); ok {
+        return hasher.Hashrate()
+    }
     
-    for _, poolTx := range bc.miningPool {
-        found := false
-        for _, minedTx := range minedTxs {
-            if poolTx.ID == minedTx.ID {
-                found = true
-                break
-            }
-        }
-        if !found {
-            newPool = append(newPool, poolTx)
-        }
+    return 0
+}
+
+// removeMinedTransactions removes mined transactions, and pool transactions
+// that reuse their inquiry IDs or public keys, from the pool
+func (bc *Blockchain) removeMinedTransactions(minedTxs []*Transaction) {
+    mined := make([]*mempool.Entry, 0, len(minedTxs))
+    for _, tx := range minedTxs {
+        mined = append(mined, &mempool.Entry{
+            ID:        tx.ID,
+            InquiryID: tx.InquiryID,
+            PublicKey: tx.PublicKey,
+        })
     }
     
-    bc.miningPool = newPool
+    if err := bc.pool.Remove(mined); err != nil {
+        bc.logger.Error("Failed to remove mined transactions from the pool: %v", err)
+    }
 }
 
 // inquiryExists checks if an inquiry ID already exists in the blockchain
@@ -344,30 +815,24 @@ func (bc *Blockchain) inquiryExists(inquiryID string) bool {
         }
     }
     
-    // Check in mining pool
-    for _, tx := range bc.miningPool {
-        if tx.InquiryID == inquiryID {
-            return true
-        }
-    }
-    
     return false
 }
 
+// Certification is a certification transaction with the main chain block that contains it
+type Certification struct {
+    *Transaction
+    BlockHash string `json:"block_hash"`
+    Height    uint64 `json:"height"`
+}
+
 // GetCertificationByPublicKey finds a certification by public key
-func (bc *Blockchain) GetCertificationByPublicKey(publicKey string) (*Transaction, error) {
+func (bc *Blockchain) GetCertificationByPublicKey(publicKey string) (*Certification, error) {
     // First check database cache
     cert, err := bc.db.GetCertificationByPublicKey(publicKey)
     if err == nil && cert != nil {
-        // Convert to transaction
-        tx := &Transaction{
-            PublicKey: cert.PublicKey,
-            Name:      cert.Name,
-            Surname:   cert.Surname,
-            InquiryID: cert.InquiryID,
-            Datetime:  cert.Datetime,
+        if found := bc.indexedCertification(cert); found != nil {
+            return found, nil
         }
-        return tx, nil
     }
     
     // Search in blockchain
@@ -377,10 +842,8 @@ func (bc *Blockchain) GetCertificationByPublicKey(publicKey string) (*Transactio
     // Search from newest to oldest
     for i := len(bc.blocks) - 1; i >= 0; i-- {
         block := bc.blocks[i]
-        for _, tx := range block.Transactions {
-            if tx.PublicKey == publicKey {
-                return tx, nil
-            }
+        if tx := block.GetCertificationByPublicKey(publicKey); tx != nil {
+            return &Certification{Transaction: tx, BlockHash: block.Hash(), Height: block.Header.Height}, nil
         }
     }
     
@@ -388,19 +851,13 @@ func (bc *Blockchain) GetCertificationByPublicKey(publicKey string) (*Transactio
 }
 
 // GetCertificationByIdentity finds a certification by name and surname
-func (bc *Blockchain) GetCertificationByIdentity(name, surname string) (*Transaction, error) {
+func (bc *Blockchain) GetCertificationByIdentity(name, surname string) (*Certification, error) {
     // First check database cache
     cert, err := bc.db.GetCertificationByIdentity(name, surname)
     if err == nil && cert != nil {
-        // Convert to transaction
-        tx := &Transaction{
-            PublicKey: cert.PublicKey,
-            Name:      cert.Name,
-            Surname:   cert.Surname,
-            InquiryID: cert.InquiryID,
-            Datetime:  cert.Datetime,
+        if found := bc.indexedCertification(cert); found != nil {
+            return found, nil
         }
-        return tx, nil
     }
     
     // Search in blockchain
@@ -410,16 +867,54 @@ func (bc *Blockchain) GetCertificationByIdentity(name, surname string) (*Transac
     // Search from newest to oldest
     for i := len(bc.blocks) - 1; i >= 0; i-- {
         block := bc.blocks[i]
-        for _, tx := range block.Transactions {
-            if tx.Name == name && tx.Surname == surname {
-                return tx, nil
-            }
+        if tx := block.GetCertificationByIdentity(name, surname); tx != nil {
+            return &Certification{Transaction: tx, BlockHash: block.Hash(), Height: block.Header.Height}, nil
         }
     }
     
     return nil, fmt.Errorf("certification not found")
 }
 
+// indexedCertification loads the full transaction of a certification index entry from its block
+func (bc *Blockchain) indexedCertification(cert *storage.Certification) *Certification {
+    block, err := bc.GetBlockByHash(cert.BlockHash)
+    if err != nil {
+        return nil
+    }
+    
+    tx := block.GetCertificationByPublicKey(cert.PublicKey)
+    if tx == nil {
+        return nil
+    }
+    
+    return &Certification{Transaction: tx, BlockHash: cert.BlockHash, Height: cert.Height}
+}
+
+// GetCertificationProof builds the inclusion proof of a certification in the main chain
+func (bc *Blockchain) GetCertificationProof(txID string) (*CertificationProof, error) {
+    block, err := bc.GetTransactionBlock(txID)
+    if err != nil {
+        return nil, err
+    }
+    
+    return block.CertificationProof(txID)
+}
+
+// GetTransactionBlock finds the main chain block that contains a transaction
+func (bc *Blockchain) GetTransactionBlock(txID string) (*Block, error) {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    // Search from newest to oldest
+    for i := len(bc.blocks) - 1; i >= 0; i-- {
+        if bc.blocks[i].GetTransactionByID(txID) != nil {
+            return bc.blocks[i], nil
+        }
+    }
+    
+    return nil, fmt.Errorf("transaction not found")
+}
+
 // GetAllBlocks returns all blocks in the blockchain
 func (bc *Blockchain) GetAllBlocks() []*Block {
     bc.mu.RLock()
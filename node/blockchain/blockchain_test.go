@@ -0,0 +1,159 @@
+package blockchain
+
+import (
+    "context"
+    "crypto/rsa"
+    "io"
+    "testing"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/config"
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+    "github.com/CertificationAgencyBlockchain/node/storage"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+// newTestChain creates a regtest chain on a temporary database
+func newTestChain(t *testing.T) *Blockchain {
+    t.Helper()
+    
+    params := chainparams.RegTest
+    cfg := &config.Config{
+        Network:    config.NetworkConfig{Chain: params.Name, MaxPeers: 8},
+        Blockchain: config.BlockchainConfig{MaxBlockSize: 1 << 20, MagicValue: params.MagicValue},
+        Mining: config.MiningConfig{
+            Engine:            "pow",
+            Threads:           1,
+            InitialDifficulty: params.InitialDifficulty,
+            DifficultyAdjust:  2016,
+            TargetBlockTime:   10 * time.Minute,
+            MaxTransPerBlock:  1000,
+        },
+        Mempool:  config.MempoolConfig{MaxSize: 1 << 20},
+        Security: config.SecurityConfig{MaxInquiryAge: 24 * time.Hour},
+    }
+    
+    db, err := storage.NewDatabase(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { db.Close() })
+    
+    logger := utils.NewLogger(false)
+    logger.SetOutput(io.Discard)
+    
+    bc, err := NewBlockchain(cfg, db, logger)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    return bc
+}
+
+// testHolder is a certificate holder with an RSA key pair
+type testHolder struct {
+    key       *rsa.PrivateKey
+    publicKey string
+}
+
+func newTestHolder(t *testing.T) *testHolder {
+    t.Helper()
+    
+    key, _, err := crypto.GenerateRSAKeyPair(1024)
+    if err != nil {
+        t.Fatal(err)
+    }
+    publicKey, err := crypto.PublicKeyToPEM(&key.PublicKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    return &testHolder{key: key, publicKey: publicKey}
+}
+
+// certify returns a signed certification of the holder made an hour ago
+func (h *testHolder) certify(t *testing.T, name, surname, inquiryID string) *Transaction {
+    t.Helper()
+    
+    tx := NewTransaction(h.publicKey, name, surname, inquiryID, time.Now().Add(-time.Hour), "")
+    signature, err := crypto.SignMessage(h.key, tx.GetSignableMessage())
+    if err != nil {
+        t.Fatal(err)
+    }
+    tx.Signature = signature
+    
+    return tx
+}
+
+// mineOn seals a block with the transactions on top of a block in the tree. The
+// offset moves the timestamp so that siblings get different hashes.
+func mineOn(t *testing.T, bc *Blockchain, parentHash string, txs []*Transaction, offset time.Duration) *Block {
+    t.Helper()
+    
+    bc.mu.RLock()
+    parent, ok := bc.index[parentHash]
+    var bits uint32
+    if ok {
+        bits = bc.calcNextBits(parent)
+    }
+    bc.mu.RUnlock()
+    if !ok {
+        t.Fatalf("parent %s is not in the block tree", parentHash)
+    }
+    
+    block := NewBlock(txs, parent.hash, parent.height+1)
+    block.Header.Bits = bits
+    block.Header.Timestamp = time.Now().Add(-30 * time.Minute).Truncate(time.Second).Add(offset)
+    if prev := parent.block.Header.Timestamp; !block.Header.Timestamp.After(prev) {
+        block.Header.Timestamp = prev.Add(time.Second)
+    }
+    
+    state, err := applyCertifications(parent.state, txs)
+    if err != nil {
+        t.Fatal(err)
+    }
+    block.Header.StateRoot = state.root()
+    
+    if err := bc.engine.Seal(context.Background(), parent, &block.Header); err != nil {
+        t.Fatal(err)
+    }
+    
+    return block
+}
+
+// poolIDs returns the IDs of the pending transactions
+func poolIDs(bc *Blockchain) map[string]bool {
+    ids := make(map[string]bool)
+    for _, tx := range bc.GetMiningPool() {
+        ids[tx.ID] = true
+    }
+    return ids
+}
+
+func TestMinedTransactionKeepsNamesakeInPool(t *testing.T) {
+    bc := newTestChain(t)
+    
+    // Two different people who happen to share a name
+    first := newTestHolder(t).certify(t, "Jane", "Doe", "inquiry-first")
+    second := newTestHolder(t).certify(t, "Jane", "Doe", "inquiry-second")
+    
+    for _, tx := range []*Transaction{first, second} {
+        if err := bc.AddTransaction(tx); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    block := mineOn(t, bc, bc.GetLatestBlock().Hash(), []*Transaction{first}, 0)
+    if err := bc.AddBlock(block); err != nil {
+        t.Fatal(err)
+    }
+    
+    pending := poolIDs(bc)
+    if pending[first.ID] {
+        t.Fatal("mined transaction is still pending")
+    }
+    if !pending[second.ID] {
+        t.Fatal("the namesake's pending transaction was dropped")
+    }
+}
\ No newline at end of file
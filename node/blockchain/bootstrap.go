@@ -0,0 +1,88 @@
+package blockchain
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+)
+
+// exportBatchSize is the number of blocks read from the chain at a time while exporting
+const exportBatchSize = 500
+
+// ExportChain writes the main chain from genesis to the tip as a sequence of
+// magic framed serialized blocks and returns the number of blocks written
+func (bc *Blockchain) ExportChain(w io.Writer) (int, error) {
+    out := bufio.NewWriter(w)
+    written := 0
+    
+    for {
+        blocks := bc.GetBlocks(uint64(written), exportBatchSize)
+        if len(blocks) == 0 {
+            break
+        }
+        
+        for _, block := range blocks {
+            data, err := block.Serialize()
+            if err != nil {
+                return written, fmt.Errorf("failed to encode block %d: %w", block.Header.Height, err)
+            }
+            
+            if err := WriteFrame(out, bc.params.MagicValue, data); err != nil {
+                return written, fmt.Errorf("failed to write block %d: %w", block.Header.Height, err)
+            }
+            written++
+        }
+    }
+    
+    if err := out.Flush(); err != nil {
+        return written, err
+    }
+    
+    return written, nil
+}
+
+// ImportChain reads magic framed serialized blocks written by ExportChain and
+// adds them with full validation. Blocks the chain already has are skipped so an
+// interrupted import can be resumed. It returns the number of blocks imported.
+func (bc *Blockchain) ImportChain(r io.Reader) (int, error) {
+    in := bufio.NewReader(r)
+    imported := 0
+    
+    for i := 0; ; i++ {
+        data, err := ReadFrame(in, bc.params.MagicValue, bc.config.Blockchain.MaxBlockSize)
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return imported, fmt.Errorf("block %d: %w", i, err)
+        }
+        
+        block, err := DeserializeBlock(data)
+        if err != nil {
+            return imported, fmt.Errorf("block %d: %w", i, err)
+        }
+        
+        // The genesis block is never imported, it must be the one of this network
+        if block.Header.Height == 0 {
+            if hash := block.Hash(); hash != bc.params.GenesisHash {
+                return imported, fmt.Errorf("file starts with genesis block %s, expected %s", hash, bc.params.GenesisHash)
+            }
+            continue
+        }
+        
+        if err := bc.AddBlock(block); err != nil {
+            if errors.Is(err, ErrBlockKnown) {
+                continue
+            }
+            return imported, fmt.Errorf("block %d rejected: %w", block.Header.Height, err)
+        }
+        imported++
+        
+        if imported%1000 == 0 {
+            bc.logger.Info("Imported %d blocks, chain height %d", imported, bc.GetHeight())
+        }
+    }
+    
+    return imported, nil
+}
\ No newline at end of file
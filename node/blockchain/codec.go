@@ -0,0 +1,377 @@
+package blockchain
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+    "unicode/utf8"
+)
+
+// Field limits of the canonical binary encoding. Decoding rejects anything longer.
+const (
+    // maxHashLength bounds hex encoded hashes and fingerprints
+    maxHashLength = 64
+    
+    // maxKeyLength bounds PEM encoded public keys
+    maxKeyLength = 4096
+    
+    // maxFieldLength bounds names, surnames and inquiry IDs
+    maxFieldLength = 256
+    
+    // maxSignatureLength bounds base64 encoded signatures
+    maxSignatureLength = 1024
+    
+    // maxStatusLength bounds the transaction status
+    maxStatusLength = 32
+    
+    // maxListLength bounds the number of transactions, blocks or headers in one encoding
+    maxListLength = 1 << 16
+)
+
+// BinaryContentType is the HTTP media type of messages in the canonical binary encoding
+const BinaryContentType = "application/x-certification-binary"
+
+// ErrMalformedEncoding is returned when binary data is not a canonical encoding
+var ErrMalformedEncoding = errors.New("malformed binary encoding")
+
+// encoder writes the canonical binary encoding. The first error sticks and
+// later writes are ignored.
+type encoder struct {
+    buf []byte
+    err error
+}
+
+// uint32 writes a big endian uint32
+func (e *encoder) uint32(v uint32) {
+    e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+}
+
+// uint64 writes a big endian uint64
+func (e *encoder) uint64(v uint64) {
+    e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+}
+
+// time writes a time as Unix seconds, the precision hashes use
+func (e *encoder) time(t time.Time) {
+    e.uint64(uint64(t.Unix()))
+}
+
+// string writes a length prefixed string of at most max bytes
+func (e *encoder) string(name, s string, max int) {
+    if e.err != nil {
+        return
+    }
+    if len(s) > max {
+        e.err = fmt.Errorf("%s is %d bytes, the limit is %d", name, len(s), max)
+        return
+    }
+    
+    e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(len(s)))
+    e.buf = append(e.buf, s...)
+}
+
+// count writes the length of a list
+func (e *encoder) count(name string, n int) {
+    if e.err != nil {
+        return
+    }
+    if n > maxListLength {
+        e.err = fmt.Errorf("%s has %d entries, the limit is %d", name, n, maxListLength)
+        return
+    }
+    
+    e.uint32(uint32(n))
+}
+
+// header writes a block header
+func (e *encoder) header(h *BlockHeader) {
+    e.uint32(h.Version)
+    e.string("previous block hash", h.PrevBlockHash, maxHashLength)
+    e.string("merkle root", h.MerkleRoot, maxHashLength)
+    e.string("state root", h.StateRoot, maxHashLength)
+    e.time(h.Timestamp)
+    e.uint32(h.Bits)
+    e.uint32(h.Nonce)
+    e.uint64(h.Height)
+    e.string("signer", h.Signer, maxHashLength)
+    e.string("candidate", h.Candidate, maxKeyLength)
+    e.string("block signature", h.Signature, maxSignatureLength)
+}
+
+// transaction writes a transaction
+func (e *encoder) transaction(tx *Transaction) {
+    e.string("transaction ID", tx.ID, maxHashLength)
+    e.string("public key", tx.PublicKey, maxKeyLength)
+    e.string("name", tx.Name, maxFieldLength)
+    e.string("surname", tx.Surname, maxFieldLength)
+    e.string("inquiry ID", tx.InquiryID, maxFieldLength)
+    e.time(tx.Datetime)
+    e.string("signature", tx.Signature, maxSignatureLength)
+    e.string("status", tx.Status, maxStatusLength)
+}
+
+// block writes a block header followed by its transactions
+func (e *encoder) block(b *Block) {
+    e.header(&b.Header)
+    e.count("block", len(b.Transactions))
+    for _, tx := range b.Transactions {
+        e.transaction(tx)
+    }
+}
+
+// decoder reads the canonical binary encoding. The first error sticks and
+// later reads return zero values.
+type decoder struct {
+    data []byte
+    err  error
+}
+
+// fail records a decoding error
+func (d *decoder) fail(format string, args ...interface{}) {
+    if d.err == nil {
+        d.err = fmt.Errorf("%w: %s", ErrMalformedEncoding, fmt.Sprintf(format, args...))
+    }
+}
+
+// next consumes n bytes
+func (d *decoder) next(n int) []byte {
+    if d.err != nil {
+        return nil
+    }
+    if len(d.data) < n {
+        d.fail("unexpected end of data")
+        return nil
+    }
+    
+    b := d.data[:n]
+    d.data = d.data[n:]
+    return b
+}
+
+// uint32 reads a big endian uint32
+func (d *decoder) uint32() uint32 {
+    b := d.next(4)
+    if b == nil {
+        return 0
+    }
+    return binary.BigEndian.Uint32(b)
+}
+
+// uint64 reads a big endian uint64
+func (d *decoder) uint64() uint64 {
+    b := d.next(8)
+    if b == nil {
+        return 0
+    }
+    return binary.BigEndian.Uint64(b)
+}
+
+// time reads Unix seconds as a UTC time
+func (d *decoder) time() time.Time {
+    return time.Unix(int64(d.uint64()), 0).UTC()
+}
+
+// string reads a length prefixed UTF-8 string of at most max bytes
+func (d *decoder) string(name string, max int) string {
+    b := d.next(2)
+    if b == nil {
+        return ""
+    }
+    
+    length := int(binary.BigEndian.Uint16(b))
+    if length > max {
+        d.fail("%s is %d bytes, the limit is %d", name, length, max)
+        return ""
+    }
+    
+    s := d.next(length)
+    if s == nil {
+        return ""
+    }
+    if !utf8.Valid(s) {
+        d.fail("%s is not valid UTF-8", name)
+        return ""
+    }
+    
+    return string(s)
+}
+
+// count reads the length of a list
+func (d *decoder) count(name string) int {
+    n := d.uint32()
+    if n > maxListLength {
+        d.fail("%s has %d entries, the limit is %d", name, n, maxListLength)
+        return 0
+    }
+    
+    return int(n)
+}
+
+// header reads a block header
+func (d *decoder) header() BlockHeader {
+    var h BlockHeader
+    h.Version = d.uint32()
+    h.PrevBlockHash = d.string("previous block hash", maxHashLength)
+    h.MerkleRoot = d.string("merkle root", maxHashLength)
+    h.StateRoot = d.string("state root", maxHashLength)
+    h.Timestamp = d.time()
+    h.Bits = d.uint32()
+    h.Nonce = d.uint32()
+    h.Height = d.uint64()
+    h.Signer = d.string("signer", maxHashLength)
+    h.Candidate = d.string("candidate", maxKeyLength)
+    h.Signature = d.string("block signature", maxSignatureLength)
+    
+    return h
+}
+
+// transaction reads a transaction
+func (d *decoder) transaction() *Transaction {
+    tx := &Transaction{}
+    tx.ID = d.string("transaction ID", maxHashLength)
+    tx.PublicKey = d.string("public key", maxKeyLength)
+    tx.Name = d.string("name", maxFieldLength)
+    tx.Surname = d.string("surname", maxFieldLength)
+    tx.InquiryID = d.string("inquiry ID", maxFieldLength)
+    tx.Datetime = d.time()
+    tx.Signature = d.string("signature", maxSignatureLength)
+    tx.Status = d.string("status", maxStatusLength)
+    
+    return tx
+}
+
+// block reads a block header followed by its transactions
+func (d *decoder) block() *Block {
+    b := &Block{Header: d.header()}
+    
+    n := d.count("block")
+    for i := 0; i < n && d.err == nil; i++ {
+        b.Transactions = append(b.Transactions, d.transaction())
+    }
+    
+    return b
+}
+
+// finish checks that all data was consumed
+func (d *decoder) finish() error {
+    if d.err == nil && len(d.data) != 0 {
+        d.fail("%d trailing bytes", len(d.data))
+    }
+    
+    return d.err
+}
+
+// DeserializeBlock decodes a block from its canonical binary encoding
+func DeserializeBlock(data []byte) (*Block, error) {
+    d := &decoder{data: data}
+    block := d.block()
+    if err := d.finish(); err != nil {
+        return nil, err
+    }
+    
+    return block, nil
+}
+
+// SerializeBlocks encodes a list of blocks
+func SerializeBlocks(blocks []*Block) ([]byte, error) {
+    e := &encoder{}
+    e.count("block list", len(blocks))
+    for _, block := range blocks {
+        e.block(block)
+    }
+    
+    return e.buf, e.err
+}
+
+// DeserializeBlocks decodes a list of blocks
+func DeserializeBlocks(data []byte) ([]*Block, error) {
+    d := &decoder{data: data}
+    
+    n := d.count("block list")
+    blocks := make([]*Block, 0, n)
+    for i := 0; i < n && d.err == nil; i++ {
+        blocks = append(blocks, d.block())
+    }
+    
+    if err := d.finish(); err != nil {
+        return nil, err
+    }
+    
+    return blocks, nil
+}
+
+// SerializeHeaders encodes a list of block headers
+func SerializeHeaders(headers []BlockHeader) ([]byte, error) {
+    e := &encoder{}
+    e.count("header list", len(headers))
+    for i := range headers {
+        e.header(&headers[i])
+    }
+    
+    return e.buf, e.err
+}
+
+// DeserializeHeaders decodes a list of block headers
+func DeserializeHeaders(data []byte) ([]BlockHeader, error) {
+    d := &decoder{data: data}
+    
+    n := d.count("header list")
+    headers := make([]BlockHeader, 0, n)
+    for i := 0; i < n && d.err == nil; i++ {
+        headers = append(headers, d.header())
+    }
+    
+    if err := d.finish(); err != nil {
+        return nil, err
+    }
+    
+    return headers, nil
+}
+
+// WriteFrame writes a payload framed with the network magic value and its length
+func WriteFrame(w io.Writer, magic uint32, payload []byte) error {
+    var frame [8]byte
+    binary.BigEndian.PutUint32(frame[0:4], magic)
+    binary.BigEndian.PutUint32(frame[4:8], uint32(len(payload)))
+    
+    if _, err := w.Write(frame[:]); err != nil {
+        return err
+    }
+    _, err := w.Write(payload)
+    return err
+}
+
+// ReadFrame reads a framed payload of at most maxSize bytes. Frames of another
+// network are rejected. It returns io.EOF when r ends before a new frame. The
+// payload buffer grows as data arrives, so a large announced length alone does
+// not allocate memory.
+func ReadFrame(r io.Reader, magic uint32, maxSize int) ([]byte, error) {
+    var frame [8]byte
+    if _, err := io.ReadFull(r, frame[:]); err != nil {
+        if err == io.EOF {
+            return nil, err
+        }
+        return nil, fmt.Errorf("failed to read frame header: %w", err)
+    }
+    
+    if got := binary.BigEndian.Uint32(frame[0:4]); got != magic {
+        return nil, fmt.Errorf("frame magic %08x does not match network magic %08x", got, magic)
+    }
+    
+    length := binary.BigEndian.Uint32(frame[4:8])
+    if int64(length) > int64(maxSize) {
+        return nil, fmt.Errorf("frame of %d bytes exceeds the limit of %d", length, maxSize)
+    }
+    
+    payload, err := io.ReadAll(io.LimitReader(r, int64(length)))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read frame payload: %w", err)
+    }
+    if len(payload) != int(length) {
+        return nil, fmt.Errorf("failed to read frame payload: %w", io.ErrUnexpectedEOF)
+    }
+    
+    return payload, nil
+}
\ No newline at end of file
@@ -0,0 +1,231 @@
+package blockchain
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "io"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// testCodecBlock returns a block with every header field set
+func testCodecBlock(n int) *Block {
+    block := testBlock(n)
+    block.Header.Timestamp = time.Unix(1700000100, 0).UTC()
+    block.Header.StateRoot = EmptyStateRoot
+    block.Header.Bits = 0x207fffff
+    block.Header.Nonce = 42
+    block.Header.Signer = strings.Repeat("ab", 32)
+    block.Header.Candidate = "candidate-key"
+    block.Header.Signature = "block-signature"
+    
+    return block
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+    for _, n := range []int{0, 1, 3} {
+        block := testCodecBlock(n)
+        
+        data, err := block.Serialize()
+        if err != nil {
+            t.Fatal(err)
+        }
+        decoded, err := DeserializeBlock(data)
+        if err != nil {
+            t.Fatalf("%d transactions: %v", n, err)
+        }
+        
+        // An empty list decodes as nil
+        if n == 0 {
+            decoded.Transactions = block.Transactions
+        }
+        if !reflect.DeepEqual(decoded, block) {
+            t.Fatalf("%d transactions: decoded block differs\n got %+v\nwant %+v", n, decoded, block)
+        }
+        if decoded.Hash() != block.Hash() {
+            t.Fatalf("%d transactions: decoded block hashes differently", n)
+        }
+    }
+    
+    tx := testTransaction(7)
+    data, err := tx.Serialize()
+    if err != nil {
+        t.Fatal(err)
+    }
+    decoded, err := DeserializeTransaction(data)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(decoded, tx) {
+        t.Fatalf("decoded transaction differs\n got %+v\nwant %+v", decoded, tx)
+    }
+    
+    blocks := []*Block{testCodecBlock(1), testCodecBlock(2)}
+    data, err = SerializeBlocks(blocks)
+    if err != nil {
+        t.Fatal(err)
+    }
+    decodedBlocks, err := DeserializeBlocks(data)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(decodedBlocks, blocks) {
+        t.Fatal("decoded block list differs")
+    }
+    
+    headers := []BlockHeader{blocks[0].Header, blocks[1].Header}
+    data, err = SerializeHeaders(headers)
+    if err != nil {
+        t.Fatal(err)
+    }
+    decodedHeaders, err := DeserializeHeaders(data)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(decodedHeaders, headers) {
+        t.Fatal("decoded header list differs")
+    }
+}
+
+func TestCodecRejectsMalformedInput(t *testing.T) {
+    valid, err := testCodecBlock(2).Serialize()
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    // version followed by a previous block hash length prefix
+    prefix := func(length uint16) []byte {
+        b := binary.BigEndian.AppendUint32(nil, 1)
+        return binary.BigEndian.AppendUint16(b, length)
+    }
+    
+    tests := []struct {
+        name string
+        data []byte
+    }{
+        {"empty", nil},
+        {"trailing byte", append(append([]byte{}, valid...), 0)},
+        {"string longer than its limit", append(prefix(maxHashLength+1), make([]byte, maxHashLength+1)...)},
+        {"string longer than the data", append(prefix(10), "abc"...)},
+        {"invalid UTF-8", append(prefix(2), 0xff, 0xfe)},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := DeserializeBlock(tt.data); !errors.Is(err, ErrMalformedEncoding) {
+                t.Fatalf("got %v, want %v", err, ErrMalformedEncoding)
+            }
+        })
+    }
+    
+    // Every truncation of a valid encoding is rejected
+    for n := 0; n < len(valid); n++ {
+        if _, err := DeserializeBlock(valid[:n]); !errors.Is(err, ErrMalformedEncoding) {
+            t.Fatalf("block truncated to %d of %d bytes: got %v", n, len(valid), err)
+        }
+    }
+    
+    // List counts above the limit are rejected before anything is allocated
+    tooMany := binary.BigEndian.AppendUint32(nil, maxListLength+1)
+    if _, err := DeserializeBlocks(tooMany); !errors.Is(err, ErrMalformedEncoding) {
+        t.Fatalf("block list: got %v, want %v", err, ErrMalformedEncoding)
+    }
+    if _, err := DeserializeHeaders(tooMany); !errors.Is(err, ErrMalformedEncoding) {
+        t.Fatalf("header list: got %v, want %v", err, ErrMalformedEncoding)
+    }
+}
+
+func TestCodecRejectsOversizedFields(t *testing.T) {
+    tests := []struct {
+        name   string
+        modify func(tx *Transaction)
+    }{
+        {"name", func(tx *Transaction) { tx.Name = strings.Repeat("a", maxFieldLength+1) }},
+        {"public key", func(tx *Transaction) { tx.PublicKey = strings.Repeat("k", maxKeyLength+1) }},
+        {"signature", func(tx *Transaction) { tx.Signature = strings.Repeat("s", maxSignatureLength+1) }},
+        {"status", func(tx *Transaction) { tx.Status = strings.Repeat("x", maxStatusLength+1) }},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tx := testTransaction(1)
+            tt.modify(tx)
+            
+            if _, err := tx.Serialize(); err == nil {
+                t.Fatal("oversized field was encoded")
+            }
+            if err := NewBlock([]*Transaction{tx}, strings.Repeat("0", 64), 1).Validate(); err == nil {
+                t.Fatal("block with an oversized field is valid")
+            }
+        })
+    }
+}
+
+func TestReadFrame(t *testing.T) {
+    const magic = 0xfabfb5da
+    
+    frame := func(magic, length uint32, payload string) []byte {
+        b := binary.BigEndian.AppendUint32(nil, magic)
+        b = binary.BigEndian.AppendUint32(b, length)
+        return append(b, payload...)
+    }
+    
+    tests := []struct {
+        name    string
+        data    []byte
+        want    string
+        wantErr error
+        anyErr  bool
+    }{
+        {"payload", frame(magic, 5, "hello"), "hello", nil, false},
+        {"empty payload", frame(magic, 0, ""), "", nil, false},
+        {"payload at the limit", frame(magic, 8, "12345678"), "12345678", nil, false},
+        {"no frame", nil, "", io.EOF, false},
+        {"truncated header", frame(magic, 5, "")[:6], "", io.ErrUnexpectedEOF, false},
+        {"truncated payload", frame(magic, 5, "hel"), "", io.ErrUnexpectedEOF, false},
+        {"other network", frame(0x0b110907, 5, "hello"), "", nil, true},
+        {"over the limit", frame(magic, 9, "123456789"), "", nil, true},
+        {"huge announced length", frame(magic, 1<<31, "x"), "", nil, true},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            payload, err := ReadFrame(bytes.NewReader(tt.data), magic, 8)
+            
+            switch {
+            case tt.wantErr != nil:
+                if !errors.Is(err, tt.wantErr) {
+                    t.Fatalf("got %v, want %v", err, tt.wantErr)
+                }
+            case tt.anyErr:
+                if err == nil {
+                    t.Fatal("frame was accepted")
+                }
+            case err != nil:
+                t.Fatal(err)
+            case string(payload) != tt.want:
+                t.Fatalf("payload %q, want %q", payload, tt.want)
+            }
+        })
+    }
+    
+    // Frames written back to back are read one at a time
+    var buf bytes.Buffer
+    for _, payload := range []string{"first", "second"} {
+        if err := WriteFrame(&buf, magic, []byte(payload)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    for _, want := range []string{"first", "second"} {
+        payload, err := ReadFrame(&buf, magic, 8)
+        if err != nil || string(payload) != want {
+            t.Fatalf("got %q, %v, want %q", payload, err, want)
+        }
+    }
+    if _, err := ReadFrame(&buf, magic, 8); err != io.EOF {
+        t.Fatalf("after the last frame got %v, want io.EOF", err)
+    }
+}
\ No newline at end of file
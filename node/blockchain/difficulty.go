@@ -0,0 +1,53 @@
+package blockchain
+
+import (
+    "math/big"
+    
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+)
+
+// calcNextBits returns the difficulty bits a block on top of parent must carry
+func (bc *Blockchain) calcNextBits(parent *blockNode) uint32 {
+    if parent == nil {
+        return bc.engine.CalcNextDifficulty(nil, nil)
+    }
+    
+    bits := bc.engine.CalcNextDifficulty(parent, &parent.block.Header)
+    if bits != parent.block.Header.Bits {
+        bc.logger.Debug("Difficulty changes at height %d: %08x -> %08x",
+            parent.height+1, parent.block.Header.Bits, bits)
+    }
+    
+    return bits
+}
+
+// NextDifficulty returns the difficulty bits required for the next block
+func (bc *Blockchain) NextDifficulty() uint32 {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    return bc.calcNextBits(bc.tip)
+}
+
+// Engine returns the consensus engine the chain runs on
+func (bc *Blockchain) Engine() consensus.Engine {
+    return bc.engine
+}
+
+// Params returns the parameters of the network the chain belongs to
+func (bc *Blockchain) Params() *chainparams.Params {
+    return bc.params
+}
+
+// GetChainWork returns the cumulative work of the main chain
+func (bc *Blockchain) GetChainWork() *big.Int {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    if bc.tip == nil {
+        return big.NewInt(0)
+    }
+    
+    return new(big.Int).Set(bc.tip.chainWork)
+}
\ No newline at end of file
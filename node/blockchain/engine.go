@@ -0,0 +1,97 @@
+package blockchain
+
+import (
+    "crypto/rsa"
+    "errors"
+    "fmt"
+    "os"
+    
+    "github.com/CertificationAgencyBlockchain/node/config"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+)
+
+// NewEngine creates the consensus engine selected by mining.engine
+func NewEngine(cfg *config.Config) (consensus.Engine, error) {
+    switch cfg.Mining.Engine {
+    case "poa":
+        return newAuthorityEngine(cfg)
+    case "pow", "":
+        powLimit := consensus.CompactToBig(consensus.LeadingZerosToCompact(cfg.Mining.InitialDifficulty))
+        return consensus.NewProofOfWork(powLimit, uint64(cfg.Mining.DifficultyAdjust), cfg.Mining.TargetBlockTime, cfg.Mining.Threads), nil
+    default:
+        return nil, fmt.Errorf("unknown consensus engine: %s", cfg.Mining.Engine)
+    }
+}
+
+// newAuthorityEngine loads the authority keys and creates a proof of authority engine
+func newAuthorityEngine(cfg *config.Config) (consensus.Engine, error) {
+    authorities := make([]string, 0, len(cfg.Mining.Authorities))
+    for _, path := range cfg.Mining.Authorities {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read authority key: %w", err)
+        }
+        authorities = append(authorities, string(data))
+    }
+    
+    var signKey *rsa.PrivateKey
+    if cfg.Mining.AuthorityKey != "" {
+        data, err := os.ReadFile(cfg.Mining.AuthorityKey)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read signing key: %w", err)
+        }
+        
+        signKey, err = crypto.PEMToPrivateKey(string(data))
+        if err != nil {
+            return nil, fmt.Errorf("invalid signing key: %w", err)
+        }
+    }
+    
+    return consensus.NewProofOfAuthority(authorities, signKey, cfg.Mining.Period)
+}
+
+// ErrNotAuthorityChain is returned for authority operations on a proof of work chain
+var ErrNotAuthorityChain = errors.New("chain does not use proof of authority")
+
+// GetAuthorities returns the fingerprints of the authorities allowed to seal the next block
+func (bc *Blockchain) GetAuthorities() ([]string, error) {
+    poa, ok := bc.engine.(*consensus.ProofOfAuthority)
+    if !ok {
+        return nil, ErrNotAuthorityChain
+    }
+    
+    bc.mu.RLock()
+    tip := bc.tip
+    bc.mu.RUnlock()
+    
+    return poa.Signers(tip, &tip.block.Header)
+}
+
+// ProposeAuthority makes this node vote to add or remove an authority in the blocks it seals
+func (bc *Blockchain) ProposeAuthority(publicKey string, authorize bool) error {
+    poa, ok := bc.engine.(*consensus.ProofOfAuthority)
+    if !ok {
+        return ErrNotAuthorityChain
+    }
+    
+    if err := poa.Propose(publicKey, authorize); err != nil {
+        return err
+    }
+    
+    bc.logger.Info("Proposed authority vote (authorize: %t)", authorize)
+    return nil
+}
+
+// updateAuthorities passes the new main chain tip to a proof of authority engine,
+// whose standalone seal check accepts the signers after it
+func (bc *Blockchain) updateAuthorities() {
+    poa, ok := bc.engine.(*consensus.ProofOfAuthority)
+    if !ok {
+        return
+    }
+    
+    if err := poa.SetHead(bc.tip, &bc.tip.block.Header); err != nil {
+        bc.logger.Warn("Failed to update authorities at block %d: %v", bc.tip.height, err)
+    }
+}
\ No newline at end of file
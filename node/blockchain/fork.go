@@ -0,0 +1,286 @@
+package blockchain
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+    
+    "github.com/CertificationAgencyBlockchain/node/mempool"
+    "github.com/CertificationAgencyBlockchain/node/storage"
+)
+
+// blockNode represents a block in the block tree, on the main chain or a side chain
+type blockNode struct {
+    block     *Block
+    hash      string
+    parent    *blockNode
+    height    uint64
+    chainWork *big.Int
+    state     *stateNode
+}
+
+// newBlockNode creates a block tree node on top of its parent and applies the
+// block's certifications to the parent state. The genesis block starts with an
+// empty state.
+func (bc *Blockchain) newBlockNode(block *Block, parent *blockNode) (*blockNode, error) {
+    work := bc.engine.CalcWork(block.Header.Bits)
+    
+    var state *stateNode
+    if parent != nil {
+        work.Add(work, parent.chainWork)
+        
+        var err error
+        if state, err = applyCertifications(parent.state, block.Transactions); err != nil {
+            return nil, err
+        }
+    }
+    
+    return &blockNode{
+        block:     block,
+        hash:      block.Hash(),
+        parent:    parent,
+        height:    block.Header.Height,
+        chainWork: work,
+        state:     state,
+    }, nil
+}
+
+// checkStateRoot checks that the header commits to the state of the node
+func (node *blockNode) checkStateRoot() error {
+    if root := node.state.root(); root != node.block.Header.StateRoot {
+        return fmt.Errorf("state root %s does not match the computed %s", node.block.Header.StateRoot, root)
+    }
+    
+    return nil
+}
+
+// ancestor returns the ancestor of the node at the given height
+func (node *blockNode) ancestor(height uint64) *blockNode {
+    if height > node.height {
+        return nil
+    }
+    
+    n := node
+    for n != nil && n.height > height {
+        n = n.parent
+    }
+    
+    return n
+}
+
+// GetAncestor returns the header at the given height on the node's branch
+func (node *blockNode) GetAncestor(height uint64) *BlockHeader {
+    if n := node.ancestor(height); n != nil {
+        return &n.block.Header
+    }
+    
+    return nil
+}
+
+// headerChain is a branch of the block tree extended by headers that are not in the tree yet
+type headerChain struct {
+    base    *blockNode
+    headers []BlockHeader
+}
+
+// GetAncestor returns the header at the given height on the branch
+func (c *headerChain) GetAncestor(height uint64) *BlockHeader {
+    if height <= c.base.height {
+        return c.base.GetAncestor(height)
+    }
+    
+    if i := height - c.base.height - 1; i < uint64(len(c.headers)) {
+        return &c.headers[i]
+    }
+    
+    return nil
+}
+
+// isMainChain checks if a node is part of the main chain
+func (bc *Blockchain) isMainChain(node *blockNode) bool {
+    return node.height < uint64(len(bc.blocks)) && bc.blocks[node.height] == node.block
+}
+
+// connectBlock makes a node the new chain tip and indexes its certifications
+func (bc *Blockchain) connectBlock(node *blockNode) error {
+    block := node.block
+    
+    // Encode block for storage
+    data, err := block.Serialize()
+    if err != nil {
+        return fmt.Errorf("failed to encode block: %w", err)
+    }
+    
+    // Save to database
+    if err := bc.db.SaveBlock(node.hash, node.height, data, node.chainWork); err != nil {
+        return fmt.Errorf("failed to save block: %w", err)
+    }
+    
+    // Add block to chain
+    bc.blocks = append(bc.blocks, block)
+    bc.index[node.hash] = node
+    bc.tip = node
+    bc.currentHeight = node.height
+    bc.updateAuthorities()
+    
+    // Update database with certifications
+    for _, tx := range block.Transactions {
+        if err := bc.db.SaveCertification(newCertification(tx, node)); err != nil {
+            bc.logger.Error("Failed to save certification: %v", err)
+        }
+    }
+    
+    // Remove mined transactions from pool
+    bc.removeMinedTransactions(block.Transactions)
+    
+    // A block being mined on the old tip can no longer win
+    bc.abortStaleSeal(node.hash)
+    
+    bc.logger.Info("Added block %d with hash %s", node.height, node.hash)
+    return nil
+}
+
+// disconnectTip removes the chain tip and rolls back its certifications
+func (bc *Blockchain) disconnectTip() (*Block, error) {
+    node := bc.tip
+    if node.parent == nil {
+        return nil, fmt.Errorf("cannot disconnect the genesis block")
+    }
+    
+    if err := bc.db.DisconnectBlock(node.height); err != nil {
+        return nil, fmt.Errorf("failed to disconnect block %d: %w", node.height, err)
+    }
+    
+    bc.blocks = bc.blocks[:len(bc.blocks)-1]
+    bc.tip = node.parent
+    bc.currentHeight = node.parent.height
+    bc.updateAuthorities()
+    
+    // Roll back certification indexes
+    for _, tx := range node.block.Transactions {
+        if err := bc.db.DeleteCertification(newCertification(tx, node)); err != nil {
+            bc.logger.Error("Failed to roll back certification: %v", err)
+        }
+    }
+    bc.restoreCertifications(node.block.Transactions)
+    
+    bc.logger.Info("Disconnected block %d with hash %s", node.height, node.hash)
+    return node.block, nil
+}
+
+// reorganize switches the main chain to the branch ending at newTip
+func (bc *Blockchain) reorganize(newTip *blockNode) error {
+    // Find the fork point
+    var attach []*blockNode
+    fork := newTip
+    for fork != nil && !bc.isMainChain(fork) {
+        attach = append(attach, fork)
+        fork = fork.parent
+    }
+    
+    if fork == nil {
+        return fmt.Errorf("new chain does not share an ancestor with the main chain")
+    }
+    
+    bc.logger.Info("Reorganizing chain: fork at height %d, disconnecting %d blocks, connecting %d blocks",
+        fork.height, bc.tip.height-fork.height, len(attach))
+    
+    // Disconnect blocks down to the fork point
+    var detachedNodes []*blockNode
+    var detached []*Transaction
+    for bc.tip != fork {
+        node := bc.tip
+        block, err := bc.disconnectTip()
+        if err != nil {
+            bc.restoreBranch(fork, detachedNodes)
+            return fmt.Errorf("reorganization failed: %w", err)
+        }
+        detachedNodes = append(detachedNodes, node)
+        detached = append(detached, block.Transactions...)
+    }
+    
+    // Return transactions to the pool, connecting the new branch removes the ones it includes
+    bc.returnTransactionsToPool(detached)
+    
+    // Connect the new branch from the fork point upwards
+    for i := len(attach) - 1; i >= 0; i-- {
+        if err := bc.connectBlock(attach[i]); err != nil {
+            bc.restoreBranch(fork, detachedNodes)
+            return fmt.Errorf("reorganization failed: %w", err)
+        }
+    }
+    
+    return nil
+}
+
+// restoreBranch undoes a failed reorganization by disconnecting down to the
+// fork point and reconnecting the old branch, given tip first
+func (bc *Blockchain) restoreBranch(fork *blockNode, detached []*blockNode) {
+    var txs []*Transaction
+    for bc.tip != fork {
+        block, err := bc.disconnectTip()
+        if err != nil {
+            bc.logger.Error("Failed to roll back reorganization: %v", err)
+            return
+        }
+        txs = append(txs, block.Transactions...)
+    }
+    bc.returnTransactionsToPool(txs)
+    
+    for i := len(detached) - 1; i >= 0; i-- {
+        if err := bc.connectBlock(detached[i]); err != nil {
+            bc.logger.Error("Failed to reconnect block %d: %v", detached[i].height, err)
+            return
+        }
+    }
+    
+    bc.logger.Warn("Reorganization rolled back to block %d with hash %s", bc.tip.height, bc.tip.hash)
+}
+
+// restoreCertifications re-indexes the latest main chain certifications that
+// share a public key or identity with rolled back transactions
+func (bc *Blockchain) restoreCertifications(rolledBack []*Transaction) {
+    for _, tx := range rolledBack {
+        for i := len(bc.blocks) - 1; i >= 0; i-- {
+            block := bc.blocks[i]
+            match := block.GetCertificationByPublicKey(tx.PublicKey)
+            if match == nil {
+                match = block.GetCertificationByIdentity(tx.Name, tx.Surname)
+            }
+            if match == nil {
+                continue
+            }
+            
+            if err := bc.db.SaveCertification(newCertification(match, bc.index[block.Hash()])); err != nil {
+                bc.logger.Error("Failed to restore certification: %v", err)
+            }
+            break
+        }
+    }
+}
+
+// returnTransactionsToPool puts transactions from disconnected blocks back into the mining pool
+func (bc *Blockchain) returnTransactionsToPool(txs []*Transaction) {
+    for _, tx := range txs {
+        entry, err := newPoolEntry(tx)
+        if err == nil {
+            err = bc.pool.Add(entry)
+        }
+        if err != nil && !errors.Is(err, mempool.ErrKnown) {
+            bc.logger.Warn("Dropping transaction %s of a disconnected block: %v", tx.ID, err)
+        }
+    }
+}
+
+// newCertification builds the certification index entry for a transaction in a block
+func newCertification(tx *Transaction, node *blockNode) *storage.Certification {
+    return &storage.Certification{
+        PublicKey: tx.PublicKey,
+        Name:      tx.Name,
+        Surname:   tx.Surname,
+        InquiryID: tx.InquiryID,
+        Datetime:  tx.Datetime,
+        BlockHash: node.hash,
+        Height:    node.height,
+    }
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// mainChainHashes returns the hashes of the main chain blocks from genesis
+func mainChainHashes(bc *Blockchain) []string {
+    var hashes []string
+    for _, block := range bc.GetAllBlocks() {
+        hashes = append(hashes, block.Hash())
+    }
+    return hashes
+}
+
+func TestReorganizeToMoreWork(t *testing.T) {
+    bc := newTestChain(t)
+    genesis := bc.GetLatestBlock().Hash()
+    
+    holderA, holderB, holderC := newTestHolder(t), newTestHolder(t), newTestHolder(t)
+    a := holderA.certify(t, "Ada", "Lovelace", "inquiry-a")
+    b := holderB.certify(t, "Alan", "Turing", "inquiry-b")
+    c := holderC.certify(t, "Grace", "Hopper", "inquiry-c")
+    for _, tx := range []*Transaction{a, b, c} {
+        if err := bc.AddTransaction(tx); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    // The main chain mines a and b
+    m1 := mineOn(t, bc, genesis, []*Transaction{a}, 0)
+    if err := bc.AddBlock(m1); err != nil {
+        t.Fatal(err)
+    }
+    m2 := mineOn(t, bc, m1.Hash(), []*Transaction{b}, 0)
+    if err := bc.AddBlock(m2); err != nil {
+        t.Fatal(err)
+    }
+    
+    // A side chain of equal work mines c and a, and does not take over
+    s1 := mineOn(t, bc, genesis, []*Transaction{c}, time.Second)
+    if err := bc.AddBlock(s1); err != nil {
+        t.Fatal(err)
+    }
+    s2 := mineOn(t, bc, s1.Hash(), []*Transaction{a}, time.Second)
+    if err := bc.AddBlock(s2); err != nil {
+        t.Fatal(err)
+    }
+    if tip := bc.GetLatestBlock().Hash(); tip != m2.Hash() {
+        t.Fatal("side chain with equal work became the main chain")
+    }
+    
+    // One more block gives the side chain more work
+    s3 := mineOn(t, bc, s2.Hash(), nil, time.Second)
+    if err := bc.AddBlock(s3); err != nil {
+        t.Fatal(err)
+    }
+    
+    want := []string{genesis, s1.Hash(), s2.Hash(), s3.Hash()}
+    if got := mainChainHashes(bc); strings.Join(got, ",") != strings.Join(want, ",") {
+        t.Fatalf("main chain %v, want %v", got, want)
+    }
+    
+    // Only b was disconnected without being mined again
+    pending := poolIDs(bc)
+    if len(pending) != 1 || !pending[b.ID] {
+        t.Fatalf("pending transactions %v, want only %s", pending, b.ID)
+    }
+    
+    if _, err := bc.GetCertificationByPublicKey(holderB.publicKey); err == nil {
+        t.Fatal("certification of a disconnected block is still indexed")
+    }
+    cert, err := bc.GetCertificationByPublicKey(holderA.publicKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if cert.BlockHash != s2.Hash() {
+        t.Fatalf("certification indexed in block %s, want %s", cert.BlockHash, s2.Hash())
+    }
+}
+
+func TestFailedReorganizationRestoresBranch(t *testing.T) {
+    bc := newTestChain(t)
+    genesis := bc.GetLatestBlock().Hash()
+    
+    a := newTestHolder(t).certify(t, "Ada", "Lovelace", "inquiry-a")
+    b := newTestHolder(t).certify(t, "Alan", "Turing", "inquiry-b")
+    for _, tx := range []*Transaction{a, b} {
+        if err := bc.AddTransaction(tx); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    m1 := mineOn(t, bc, genesis, []*Transaction{a}, 0)
+    if err := bc.AddBlock(m1); err != nil {
+        t.Fatal(err)
+    }
+    before := mainChainHashes(bc)
+    
+    // The side chain's second block cannot be encoded, so connecting it fails
+    // after the first one has been connected
+    s1 := mineOn(t, bc, genesis, []*Transaction{b}, time.Second)
+    if err := bc.AddBlock(s1); err != nil {
+        t.Fatal(err)
+    }
+    
+    bad := mineOn(t, bc, s1.Hash(), nil, time.Second)
+    bad.Header.Signer = strings.Repeat("s", maxHashLength+1)
+    
+    bc.mu.Lock()
+    node, err := bc.newBlockNode(bad, bc.index[s1.Hash()])
+    if err != nil {
+        bc.mu.Unlock()
+        t.Fatal(err)
+    }
+    bc.index[node.hash] = node
+    err = bc.reorganize(node)
+    bc.mu.Unlock()
+    
+    if err == nil {
+        t.Fatal("reorganization to a block that cannot be stored succeeded")
+    }
+    if got := mainChainHashes(bc); strings.Join(got, ",") != strings.Join(before, ",") {
+        t.Fatalf("main chain %v after the failed reorganization, want %v", got, before)
+    }
+    
+    // The side chain's transaction went back to the pool, the main chain's did not
+    pending := poolIDs(bc)
+    if len(pending) != 1 || !pending[b.ID] {
+        t.Fatalf("pending transactions %v, want only %s", pending, b.ID)
+    }
+    
+    // The stored chain is the restored one
+    reloaded, err := NewBlockchain(bc.config, bc.db, bc.logger)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := mainChainHashes(reloaded); strings.Join(got, ",") != strings.Join(before, ",") {
+        t.Fatalf("stored main chain %v, want %v", got, before)
+    }
+}
\ No newline at end of file
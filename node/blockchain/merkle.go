@@ -2,186 +2,205 @@ package blockchain
 
 import (
     "crypto/sha256"
+    "encoding/binary"
     "encoding/hex"
     "fmt"
 )
 
-// MerkleNode represents a node in the Merkle tree
-type MerkleNode struct {
-    Left  *MerkleNode
-    Right *MerkleNode
-    Hash  string
+// Leaves and inner nodes are hashed with different prefixes so that an inner
+// node can never be passed off as a transaction. The root hashes the top node
+// with the number of leaves, so a proof cannot restate its position in a tree
+// of another size.
+const (
+    merkleLeafPrefix = 0x00
+    merkleNodePrefix = 0x01
+    merkleRootPrefix = 0x02
+)
+
+// MerkleProof proves that a transaction is a leaf of a block's Merkle root
+type MerkleProof struct {
+    Index    int      `json:"index"`
+    Leaves   int      `json:"leaves"`
+    Siblings []string `json:"siblings"`
+}
+
+// merkleLeaf hashes the canonical encoding of a transaction into a leaf, so the
+// root commits to every field of the transaction including its signature
+func merkleLeaf(tx *Transaction) ([]byte, error) {
+    data, err := tx.Serialize()
+    if err != nil {
+        return nil, fmt.Errorf("transaction %s cannot be encoded: %w", tx.ID, err)
+    }
+    
+    hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, data...))
+    return hash[:], nil
 }
 
-// MerkleTree represents a Merkle tree
-type MerkleTree struct {
-    Root *MerkleNode
+// merkleNode hashes two child nodes into their parent
+func merkleNode(left, right []byte) []byte {
+    data := make([]byte, 0, 1+len(left)+len(right))
+    data = append(data, merkleNodePrefix)
+    data = append(data, left...)
+    data = append(data, right...)
+    
+    hash := sha256.Sum256(data)
+    return hash[:]
 }
 
-// NewMerkleNode creates a new Merkle node
-func NewMerkleNode(left, right *MerkleNode, data []byte) *MerkleNode {
-    node := &MerkleNode{}
-    
-    if left == nil && right == nil {
-        // Leaf node
-        hash := sha256.Sum256(data)
-        node.Hash = hex.EncodeToString(hash[:])
-    } else {
-        // Non-leaf node
-        var prevHashes []byte
-        if left != nil {
-            h, _ := hex.DecodeString(left.Hash)
-            prevHashes = append(prevHashes, h...)
+// merkleRoot commits the top node of the tree to the number of leaves under it
+func merkleRoot(top []byte, leaves int) string {
+    data := make([]byte, 0, 9+len(top))
+    data = append(data, merkleRootPrefix)
+    data = binary.BigEndian.AppendUint64(data, uint64(leaves))
+    data = append(data, top...)
+    
+    hash := sha256.Sum256(data)
+    return hex.EncodeToString(hash[:])
+}
+
+// merkleLevels builds the tree bottom up and returns every level, leaves first.
+// A node without a sibling moves up unchanged instead of being paired with a
+// copy of itself, so no two lists of transactions share a root.
+func merkleLevels(leaves [][]byte) [][][]byte {
+    levels := [][][]byte{leaves}
+    
+    for level := leaves; len(level) > 1; {
+        next := make([][]byte, 0, (len(level)+1)/2)
+        for i := 0; i+1 < len(level); i += 2 {
+            next = append(next, merkleNode(level[i], level[i+1]))
         }
-        if right != nil {
-            h, _ := hex.DecodeString(right.Hash)
-            prevHashes = append(prevHashes, h...)
+        if len(level)%2 == 1 {
+            next = append(next, level[len(level)-1])
         }
         
-        hash := sha256.Sum256(prevHashes)
-        node.Hash = hex.EncodeToString(hash[:])
+        levels = append(levels, next)
+        level = next
     }
     
-    node.Left = left
-    node.Right = right
-    
-    return node
+    return levels
 }
 
-// NewMerkleTree creates a new Merkle tree from transaction data
-func NewMerkleTree(data [][]byte) *MerkleTree {
-    var nodes []*MerkleNode
-    
-    // Create leaf nodes
-    for _, datum := range data {
-        node := NewMerkleNode(nil, nil, datum)
-        nodes = append(nodes, node)
+// transactionLeaves hashes the transactions of a block into Merkle leaves
+func transactionLeaves(transactions []*Transaction) ([][]byte, error) {
+    leaves := make([][]byte, 0, len(transactions))
+    for _, tx := range transactions {
+        leaf, err := merkleLeaf(tx)
+        if err != nil {
+            return nil, err
+        }
+        leaves = append(leaves, leaf)
     }
     
-    // If odd number of nodes, duplicate the last one
-    if len(nodes)%2 != 0 {
-        nodes = append(nodes, nodes[len(nodes)-1])
+    return leaves, nil
+}
+
+// CalculateMerkleRoot calculates the Merkle root of the block's transactions. A
+// block without transactions has an empty root.
+func (b *Block) CalculateMerkleRoot() (string, error) {
+    if len(b.Transactions) == 0 {
+        return "", nil
     }
     
-    // Build tree
-    for len(nodes) > 1 {
-        var level []*MerkleNode
-        
-        for i := 0; i < len(nodes); i += 2 {
-            node := NewMerkleNode(nodes[i], nodes[i+1], nil)
-            level = append(level, node)
-        }
-        
-        nodes = level
-        
-        // If odd number of nodes at this level, duplicate the last one
-        if len(nodes) > 1 && len(nodes)%2 != 0 {
-            nodes = append(nodes, nodes[len(nodes)-1])
-        }
+    leaves, err := transactionLeaves(b.Transactions)
+    if err != nil {
+        return "", err
     }
     
-    tree := &MerkleTree{Root: nodes[0]}
-    return tree
+    levels := merkleLevels(leaves)
+    return merkleRoot(levels[len(levels)-1][0], len(leaves)), nil
 }
 
-// GetRootHash returns the root hash of the Merkle tree
-func (mt *MerkleTree) GetRootHash() string {
-    if mt.Root != nil {
-        return mt.Root.Hash
+// checkMerkleRoot checks that the header commits to the block's transactions
+func (b *Block) checkMerkleRoot() error {
+    root, err := b.CalculateMerkleRoot()
+    if err != nil {
+        return fmt.Errorf("invalid merkle root: %w", err)
     }
-    return ""
-}
-
-// VerifyTransaction verifies that a transaction is in the Merkle tree
-func (mt *MerkleTree) VerifyTransaction(transactionHash string, merkleProof []string, index int) bool {
-    computedHash := transactionHash
-    
-    for i, proofElement := range merkleProof {
-        var combinedHash []byte
-        h1, _ := hex.DecodeString(computedHash)
-        h2, _ := hex.DecodeString(proofElement)
-        
-        // Determine order based on index
-        if (index>>uint(i))&1 == 0 {
-            combinedHash = append(h1, h2...)
-        } else {
-            combinedHash = append(h2, h1...)
-        }
-        
-        hash := sha256.Sum256(combinedHash)
-        computedHash = hex.EncodeToString(hash[:])
+    if root != b.Header.MerkleRoot {
+        return fmt.Errorf("invalid merkle root")
     }
     
-    return computedHash == mt.Root.Hash
+    return nil
 }
 
-// GenerateMerkleProof generates a Merkle proof for a transaction
-func GenerateMerkleProof(transactions []*Transaction, targetIndex int) ([]string, error) {
-    if targetIndex < 0 || targetIndex >= len(transactions) {
-        return nil, fmt.Errorf("invalid transaction index")
+// MerkleProof builds the proof that the transaction with the given ID is part of the block
+func (b *Block) MerkleProof(txID string) (*MerkleProof, error) {
+    index := -1
+    for i, tx := range b.Transactions {
+        if tx.ID == txID {
+            index = i
+            break
+        }
     }
     
-    // Convert transactions to byte arrays
-    var data [][]byte
-    for _, tx := range transactions {
-        txBytes, _ := tx.Serialize()
-        data = append(data, txBytes)
+    if index < 0 {
+        return nil, fmt.Errorf("transaction %s is not in block %d", txID, b.Header.Height)
     }
     
-    // Build proof
-    proof := []string{}
-    nodes := make([]string, len(data))
-    
-    // Create leaf hashes
-    for i, datum := range data {
-        hash := sha256.Sum256(datum)
-        nodes[i] = hex.EncodeToString(hash[:])
+    leaves, err := transactionLeaves(b.Transactions)
+    if err != nil {
+        return nil, err
     }
     
-    currentIndex := targetIndex
+    levels := merkleLevels(leaves)
+    proof := &MerkleProof{Index: index, Leaves: len(b.Transactions), Siblings: []string{}}
     
-    for len(nodes) > 1 {
-        // If odd number, duplicate last node
-        if len(nodes)%2 != 0 {
-            nodes = append(nodes, nodes[len(nodes)-1])
-        }
-        
-        // Add sibling to proof
-        siblingIndex := currentIndex ^ 1
-        if siblingIndex < len(nodes) {
-            proof = append(proof, nodes[siblingIndex])
-        }
-        
-        // Build next level
-        var nextLevel []string
-        for i := 0; i < len(nodes); i += 2 {
-            h1, _ := hex.DecodeString(nodes[i])
-            h2, _ := hex.DecodeString(nodes[i+1])
-            combined := append(h1, h2...)
-            hash := sha256.Sum256(combined)
-            nextLevel = append(nextLevel, hex.EncodeToString(hash[:]))
+    pos := index
+    for _, level := range levels[:len(levels)-1] {
+        sibling := pos ^ 1
+        if sibling < len(level) {
+            proof.Siblings = append(proof.Siblings, hex.EncodeToString(level[sibling]))
         }
-        
-        nodes = nextLevel
-        currentIndex = currentIndex / 2
+        pos /= 2
     }
     
     return proof, nil
 }
 
-// CalculateMerkleRootFromTransactions calculates the Merkle root from a list of transactions
-func CalculateMerkleRootFromTransactions(transactions []*Transaction) string {
-    if len(transactions) == 0 {
-        return ""
+// Verify checks that the proof connects a transaction to a Merkle root
+func (p *MerkleProof) Verify(tx *Transaction, root string) error {
+    if p.Leaves < 1 || p.Index < 0 || p.Index >= p.Leaves {
+        return fmt.Errorf("proof index %d is out of range for %d leaves", p.Index, p.Leaves)
     }
     
-    // Convert transactions to byte arrays
-    var data [][]byte
-    for _, tx := range transactions {
-        txBytes, _ := tx.Serialize()
-        data = append(data, txBytes)
+    hash, err := merkleLeaf(tx)
+    if err != nil {
+        return err
+    }
+    
+    used := 0
+    pos, width := p.Index, p.Leaves
+    for width > 1 {
+        // The last node of an odd level has no sibling and moves up as it is
+        if pos^1 < width {
+            if used >= len(p.Siblings) {
+                return fmt.Errorf("proof has too few siblings")
+            }
+            
+            sibling, err := hex.DecodeString(p.Siblings[used])
+            if err != nil || len(sibling) != sha256.Size {
+                return fmt.Errorf("invalid sibling %q", p.Siblings[used])
+            }
+            used++
+            
+            if pos%2 == 0 {
+                hash = merkleNode(hash, sibling)
+            } else {
+                hash = merkleNode(sibling, hash)
+            }
+        }
+        
+        pos /= 2
+        width = (width + 1) / 2
+    }
+    
+    if used != len(p.Siblings) {
+        return fmt.Errorf("proof has too many siblings")
+    }
+    
+    if merkleRoot(hash, p.Leaves) != root {
+        return fmt.Errorf("proof does not lead to Merkle root %s", root)
     }
     
-    tree := NewMerkleTree(data)
-    return tree.GetRootHash()
+    return nil
 }
\ No newline at end of file
@@ -0,0 +1,178 @@
+package blockchain
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+)
+
+// testTransaction returns a distinct certification with a placeholder signature
+func testTransaction(i int) *Transaction {
+    return NewTransaction(
+        fmt.Sprintf("public-key-%d", i),
+        fmt.Sprintf("Name%d", i),
+        fmt.Sprintf("Surname%d", i),
+        fmt.Sprintf("inquiry-%d", i),
+        time.Unix(1700000000+int64(i), 0).UTC(),
+        fmt.Sprintf("signature-%d", i),
+    )
+}
+
+// testBlock returns a block with n distinct transactions
+func testBlock(n int) *Block {
+    txs := make([]*Transaction, 0, n)
+    for i := 0; i < n; i++ {
+        txs = append(txs, testTransaction(i))
+    }
+    
+    return NewBlock(txs, strings.Repeat("0", 64), 1)
+}
+
+func TestMerkleProofs(t *testing.T) {
+    for leaves := 1; leaves <= 9; leaves++ {
+        block := testBlock(leaves)
+        root := block.Header.MerkleRoot
+        
+        for i, tx := range block.Transactions {
+            proof, err := block.MerkleProof(tx.ID)
+            if err != nil {
+                t.Fatalf("%d leaves, index %d: %v", leaves, i, err)
+            }
+            if proof.Index != i || proof.Leaves != leaves {
+                t.Fatalf("%d leaves, index %d: proof is for index %d of %d", leaves, i, proof.Index, proof.Leaves)
+            }
+            
+            if err := proof.Verify(tx, root); err != nil {
+                t.Fatalf("%d leaves, index %d: %v", leaves, i, err)
+            }
+        }
+    }
+}
+
+func TestMerkleProofRejectsTampering(t *testing.T) {
+    block := testBlock(7)
+    root := block.Header.MerkleRoot
+    
+    // The last leaf of an odd level has no sibling, index 4 is paired and 6 is not
+    for _, index := range []int{0, 4, 6} {
+        tx := block.Transactions[index]
+        
+        tests := []struct {
+            name   string
+            tamper func(p *MerkleProof, tx *Transaction) string
+        }{
+            {"changed name", func(p *MerkleProof, tx *Transaction) string {
+                tx.Name += "x"
+                return root
+            }},
+            {"changed signature", func(p *MerkleProof, tx *Transaction) string {
+                tx.Signature += "x"
+                return root
+            }},
+            {"other index", func(p *MerkleProof, tx *Transaction) string {
+                p.Index ^= 1
+                return root
+            }},
+            {"index out of range", func(p *MerkleProof, tx *Transaction) string {
+                p.Index = p.Leaves
+                return root
+            }},
+            {"extra sibling", func(p *MerkleProof, tx *Transaction) string {
+                p.Siblings = append(p.Siblings, strings.Repeat("00", 32))
+                return root
+            }},
+            {"missing sibling", func(p *MerkleProof, tx *Transaction) string {
+                p.Siblings = p.Siblings[:len(p.Siblings)-1]
+                return root
+            }},
+            {"malformed sibling", func(p *MerkleProof, tx *Transaction) string {
+                p.Siblings[0] = "zz"
+                return root
+            }},
+            {"other root", func(p *MerkleProof, tx *Transaction) string {
+                return strings.Repeat("00", 32)
+            }},
+        }
+        
+        for _, tt := range tests {
+            t.Run(fmt.Sprintf("index %d %s", index, tt.name), func(t *testing.T) {
+                proof, err := block.MerkleProof(tx.ID)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                
+                candidate := tx.Clone()
+                wantRoot := tt.tamper(proof, candidate)
+                if err := proof.Verify(candidate, wantRoot); err == nil {
+                    t.Fatal("tampered proof verified")
+                }
+            })
+        }
+    }
+}
+
+func TestMerkleProofRejectsOtherLeafCount(t *testing.T) {
+    block := testBlock(3)
+    root := block.Header.MerkleRoot
+    
+    // The last of three leaves moves up unpaired, so its siblings are the same
+    // as those of the second of two leaves
+    proof, err := block.MerkleProof(block.Transactions[2].ID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    proof.Index, proof.Leaves = 1, 2
+    
+    if err := proof.Verify(block.Transactions[2], root); err == nil {
+        t.Fatal("proof restated for another leaf count verified")
+    }
+}
+
+func TestMerkleRootOddLeafCount(t *testing.T) {
+    // Repeating the last transaction must not reproduce the root of the shorter list
+    three := testBlock(3)
+    four := NewBlock(append(three.Transactions[:3:3], three.Transactions[2]), three.Header.PrevBlockHash, 1)
+    
+    if three.Header.MerkleRoot == four.Header.MerkleRoot {
+        t.Fatal("duplicating the last transaction kept the Merkle root")
+    }
+    
+    empty := NewBlock(nil, three.Header.PrevBlockHash, 1)
+    if empty.Header.MerkleRoot != "" {
+        t.Fatalf("empty block root = %q, want empty", empty.Header.MerkleRoot)
+    }
+}
+
+func TestCertificationProofVerify(t *testing.T) {
+    block := testBlock(5)
+    tx := block.Transactions[3]
+    
+    tests := []struct {
+        name    string
+        tamper  func(p *CertificationProof)
+        wantErr bool
+    }{
+        {"valid", func(p *CertificationProof) {}, false},
+        {"no transaction", func(p *CertificationProof) { p.Transaction = nil }, true},
+        {"ID does not match contents", func(p *CertificationProof) { p.Transaction.Surname = "Other" }, true},
+        {"other block hash", func(p *CertificationProof) { p.BlockHash = strings.Repeat("00", 32) }, true},
+        {"other height", func(p *CertificationProof) { p.Height++ }, true},
+        {"other root", func(p *CertificationProof) { p.Header.MerkleRoot = strings.Repeat("00", 32) }, true},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            proof, err := block.CertificationProof(tx.ID)
+            if err != nil {
+                t.Fatal(err)
+            }
+            proof.Transaction = proof.Transaction.Clone()
+            
+            tt.tamper(proof)
+            if err := proof.Verify(); (err != nil) != tt.wantErr {
+                t.Fatalf("Verify() = %v, want error %v", err, tt.wantErr)
+            }
+        })
+    }
+}
\ No newline at end of file
@@ -0,0 +1,170 @@
+package blockchain
+
+import (
+    "errors"
+    "time"
+)
+
+const (
+    // maxOrphanBlocks is the maximum number of orphan blocks kept in memory
+    maxOrphanBlocks = 100
+    
+    // maxOrphanDepth is how far ahead of the chain tip an orphan may be
+    maxOrphanDepth = 288
+    
+    // orphanExpiration is how long an orphan is kept while waiting for its parent
+    orphanExpiration = time.Hour
+)
+
+// ErrOrphanBlock is returned when a block's parent is not known yet
+var ErrOrphanBlock = errors.New("orphan block")
+
+// orphanBlock is a block waiting for its parent to arrive
+type orphanBlock struct {
+    block      *Block
+    hash       string
+    expiration time.Time
+}
+
+// orphanPool holds blocks whose parent is unknown, keyed by hash and by previous block hash
+type orphanPool struct {
+    byHash map[string]*orphanBlock
+    byPrev map[string][]*orphanBlock
+}
+
+// newOrphanPool creates an empty orphan pool
+func newOrphanPool() *orphanPool {
+    return &orphanPool{
+        byHash: make(map[string]*orphanBlock),
+        byPrev: make(map[string][]*orphanBlock),
+    }
+}
+
+// has checks if a block is in the orphan pool
+func (op *orphanPool) has(hash string) bool {
+    _, ok := op.byHash[hash]
+    return ok
+}
+
+// add adds a block to the orphan pool, evicting old orphans when the pool is full
+func (op *orphanPool) add(block *Block, hash string) {
+    if op.has(hash) {
+        return
+    }
+    
+    // Evict expired orphans first
+    now := time.Now()
+    for _, orphan := range op.byHash {
+        if now.After(orphan.expiration) {
+            op.remove(orphan)
+        }
+    }
+    
+    // Evict the oldest orphan if the pool is still full
+    if len(op.byHash) >= maxOrphanBlocks {
+        var oldest *orphanBlock
+        for _, orphan := range op.byHash {
+            if oldest == nil || orphan.expiration.Before(oldest.expiration) {
+                oldest = orphan
+            }
+        }
+        op.remove(oldest)
+    }
+    
+    orphan := &orphanBlock{
+        block:      block,
+        hash:       hash,
+        expiration: now.Add(orphanExpiration),
+    }
+    
+    op.byHash[hash] = orphan
+    prev := block.Header.PrevBlockHash
+    op.byPrev[prev] = append(op.byPrev[prev], orphan)
+}
+
+// remove removes an orphan from both indexes
+func (op *orphanPool) remove(orphan *orphanBlock) {
+    delete(op.byHash, orphan.hash)
+    
+    prev := orphan.block.Header.PrevBlockHash
+    siblings := op.byPrev[prev]
+    for i, sibling := range siblings {
+        if sibling.hash == orphan.hash {
+            siblings = append(siblings[:i], siblings[i+1:]...)
+            break
+        }
+    }
+    
+    if len(siblings) == 0 {
+        delete(op.byPrev, prev)
+    } else {
+        op.byPrev[prev] = siblings
+    }
+}
+
+// takeChildren removes and returns the orphans whose parent is the given block
+func (op *orphanPool) takeChildren(parentHash string) []*orphanBlock {
+    children := op.byPrev[parentHash]
+    for _, child := range children {
+        op.remove(child)
+    }
+    return children
+}
+
+// missingParent returns the hash of the first unknown ancestor of an orphan
+func (op *orphanPool) missingParent(hash string) string {
+    orphan, ok := op.byHash[hash]
+    if !ok {
+        return ""
+    }
+    
+    for {
+        parent, ok := op.byHash[orphan.block.Header.PrevBlockHash]
+        if !ok {
+            return orphan.block.Header.PrevBlockHash
+        }
+        orphan = parent
+    }
+}
+
+// size returns the number of orphans in the pool
+func (op *orphanPool) size() int {
+    return len(op.byHash)
+}
+
+// GetMissingParent returns the hash of the block needed to connect an orphan, or
+// an empty string if the block is not an orphan
+func (bc *Blockchain) GetMissingParent(orphanHash string) string {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    return bc.orphans.missingParent(orphanHash)
+}
+
+// GetOrphanCount returns the number of blocks waiting for their parent
+func (bc *Blockchain) GetOrphanCount() int {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    return bc.orphans.size()
+}
+
+// processOrphans connects orphans that were waiting for the given block
+func (bc *Blockchain) processOrphans(hash string) {
+    queue := []string{hash}
+    
+    for len(queue) > 0 {
+        parentHash := queue[0]
+        queue = queue[1:]
+        
+        for _, orphan := range bc.orphans.takeChildren(parentHash) {
+            if err := bc.addBlock(orphan.block); err != nil {
+                bc.logger.Warn("Failed to connect orphan block %s: %v", orphan.hash, err)
+                continue
+            }
+            
+            bc.logger.Info("Connected orphan block %d with hash %s", orphan.block.Header.Height, orphan.hash)
+            queue = append(queue, orphan.hash)
+        }
+    }
+}
\ No newline at end of file
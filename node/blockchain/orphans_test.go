@@ -0,0 +1,109 @@
+package blockchain
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+)
+
+// orphanTestBlock returns a block whose parent is unknown
+func orphanTestBlock(i int) *Block {
+    return NewBlock(nil, fmt.Sprintf("%064x", i), 1)
+}
+
+func TestOrphansConnectWhenParentArrives(t *testing.T) {
+    bc, other := newTestChain(t), newTestChain(t)
+    
+    // Another node mines three blocks that arrive in reverse order
+    var blocks []*Block
+    parent := other.GetLatestBlock().Hash()
+    for i := 0; i < 3; i++ {
+        block := mineOn(t, other, parent, nil, 0)
+        if err := other.AddBlock(block); err != nil {
+            t.Fatal(err)
+        }
+        blocks = append(blocks, block)
+        parent = block.Hash()
+    }
+    
+    for _, block := range []*Block{blocks[2], blocks[1]} {
+        if err := bc.AddBlock(block); !errors.Is(err, ErrOrphanBlock) {
+            t.Fatalf("block %d: got %v, want %v", block.Header.Height, err, ErrOrphanBlock)
+        }
+    }
+    if n := bc.GetOrphanCount(); n != 2 {
+        t.Fatalf("%d orphans, want 2", n)
+    }
+    if missing := bc.GetMissingParent(blocks[2].Hash()); missing != blocks[0].Hash() {
+        t.Fatalf("missing parent %s, want %s", missing, blocks[0].Hash())
+    }
+    
+    // The missing block connects the orphans behind it
+    if err := bc.AddBlock(blocks[0]); err != nil {
+        t.Fatal(err)
+    }
+    if tip := bc.GetLatestBlock().Hash(); tip != blocks[2].Hash() {
+        t.Fatalf("tip %s, want %s", tip, blocks[2].Hash())
+    }
+    if n := bc.GetOrphanCount(); n != 0 {
+        t.Fatalf("%d orphans left after connecting", n)
+    }
+}
+
+func TestOrphanPoolExpiry(t *testing.T) {
+    op := newOrphanPool()
+    
+    expired, kept := orphanTestBlock(1), orphanTestBlock(2)
+    op.add(expired, expired.Hash())
+    op.add(kept, kept.Hash())
+    op.byHash[expired.Hash()].expiration = time.Now().Add(-time.Second)
+    
+    // Expired orphans are dropped when the next one arrives
+    next := orphanTestBlock(3)
+    op.add(next, next.Hash())
+    
+    if op.has(expired.Hash()) {
+        t.Fatal("expired orphan is still in the pool")
+    }
+    if _, ok := op.byPrev[expired.Header.PrevBlockHash]; ok {
+        t.Fatal("expired orphan is still indexed by its parent")
+    }
+    if !op.has(kept.Hash()) || !op.has(next.Hash()) || op.size() != 2 {
+        t.Fatalf("pool holds %d orphans, want the two unexpired ones", op.size())
+    }
+}
+
+func TestOrphanPoolCap(t *testing.T) {
+    op := newOrphanPool()
+    start := time.Now().Add(time.Minute)
+    
+    const extra = 5
+    var blocks []*Block
+    for i := 0; i < maxOrphanBlocks+extra; i++ {
+        block := orphanTestBlock(i)
+        op.add(block, block.Hash())
+        op.byHash[block.Hash()].expiration = start.Add(time.Duration(i) * time.Second)
+        blocks = append(blocks, block)
+    }
+    
+    if op.size() != maxOrphanBlocks {
+        t.Fatalf("pool holds %d orphans, want %d", op.size(), maxOrphanBlocks)
+    }
+    if len(op.byPrev) != maxOrphanBlocks {
+        t.Fatalf("%d parents indexed, want %d", len(op.byPrev), maxOrphanBlocks)
+    }
+    
+    // The orphans closest to expiring were evicted first
+    for i, block := range blocks {
+        if got, want := op.has(block.Hash()), i >= extra; got != want {
+            t.Fatalf("orphan %d: in pool %v, want %v", i, got, want)
+        }
+    }
+    
+    // Adding a known orphan again changes nothing
+    op.add(blocks[len(blocks)-1], blocks[len(blocks)-1].Hash())
+    if op.size() != maxOrphanBlocks || !op.has(blocks[extra].Hash()) {
+        t.Fatal("adding a known orphan evicted another one")
+    }
+}
\ No newline at end of file
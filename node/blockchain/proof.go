@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+    "fmt"
+)
+
+// CertificationProof proves that a certification is part of a block. It carries
+// everything needed to check it offline except the trust in the block header.
+type CertificationProof struct {
+    Transaction *Transaction `json:"transaction"`
+    BlockHash   string       `json:"block_hash"`
+    Height      uint64       `json:"height"`
+    Header      BlockHeader  `json:"header"`
+    Proof       MerkleProof  `json:"proof"`
+}
+
+// CertificationProof builds the inclusion proof of the transaction with the given ID
+func (b *Block) CertificationProof(txID string) (*CertificationProof, error) {
+    tx := b.GetTransactionByID(txID)
+    if tx == nil {
+        return nil, fmt.Errorf("transaction %s is not in block %d", txID, b.Header.Height)
+    }
+    
+    proof, err := b.MerkleProof(txID)
+    if err != nil {
+        return nil, err
+    }
+    
+    return &CertificationProof{
+        Transaction: tx,
+        BlockHash:   b.Hash(),
+        Height:      b.Header.Height,
+        Header:      b.Header,
+        Proof:       *proof,
+    }, nil
+}
+
+// Verify checks that the transaction matches its ID, that the header matches the
+// block hash and height, and that the Merkle proof leads from the transaction's
+// encoding to the header's root
+func (p *CertificationProof) Verify() error {
+    if p.Transaction == nil {
+        return fmt.Errorf("proof has no transaction")
+    }
+    
+    if p.Transaction.ID != p.Transaction.Hash() {
+        return fmt.Errorf("transaction ID does not match its hash")
+    }
+    
+    if hash := p.Header.Hash(); hash != p.BlockHash {
+        return fmt.Errorf("header hashes to %s, expected block %s", hash, p.BlockHash)
+    }
+    if p.Header.Height != p.Height {
+        return fmt.Errorf("header is at height %d, expected %d", p.Header.Height, p.Height)
+    }
+    
+    return p.Proof.Verify(p.Transaction, p.Header.MerkleRoot)
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+    "fmt"
+    "runtime"
+    "sync"
+)
+
+// maxSigCacheEntries bounds the number of verified signatures kept in memory
+const maxSigCacheEntries = 50000
+
+// sigCache remembers the signatures of transactions that were already verified.
+// Entries are keyed by transaction ID and the signature is compared on lookup,
+// since the ID does not cover the signature.
+type sigCache struct {
+    mu      sync.RWMutex
+    entries map[string]string
+}
+
+// newSigCache creates an empty signature cache
+func newSigCache() *sigCache {
+    return &sigCache{
+        entries: make(map[string]string),
+    }
+}
+
+// has checks if the transaction's signature was already verified
+func (c *sigCache) has(tx *Transaction) bool {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    
+    signature, ok := c.entries[tx.ID]
+    return ok && signature == tx.Signature
+}
+
+// add records a verified signature, evicting an arbitrary entry when the cache is full
+func (c *sigCache) add(tx *Transaction) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    if _, ok := c.entries[tx.ID]; !ok && len(c.entries) >= maxSigCacheEntries {
+        for id := range c.entries {
+            delete(c.entries, id)
+            break
+        }
+    }
+    
+    c.entries[tx.ID] = tx.Signature
+}
+
+// verifySignature verifies a transaction signature unless it is cached
+func (bc *Blockchain) verifySignature(tx *Transaction) error {
+    if bc.sigCache.has(tx) {
+        return nil
+    }
+    
+    if err := tx.VerifySignature(); err != nil {
+        return err
+    }
+    
+    bc.sigCache.add(tx)
+    return nil
+}
+
+// verifyBlockSignatures verifies the signatures of all transactions in a block on
+// a bounded pool of workers and returns the first failure
+func (bc *Blockchain) verifyBlockSignatures(block *Block) error {
+    workers := runtime.NumCPU()
+    if workers > len(block.Transactions) {
+        workers = len(block.Transactions)
+    }
+    
+    jobs := make(chan *Transaction)
+    done := make(chan struct{})
+    
+    var (
+        wg       sync.WaitGroup
+        errOnce  sync.Once
+        firstErr error
+    )
+    
+    for i := 0; i < workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            
+            for tx := range jobs {
+                if err := bc.verifySignature(tx); err != nil {
+                    errOnce.Do(func() {
+                        firstErr = fmt.Errorf("transaction %s has an invalid signature: %w", tx.ID, err)
+                        close(done)
+                    })
+                }
+            }
+        }()
+    }
+    
+    // Stop handing out work after the first failure
+feed:
+    for _, tx := range block.Transactions {
+        select {
+        case jobs <- tx:
+        case <-done:
+            break feed
+        }
+    }
+    close(jobs)
+    
+    wg.Wait()
+    return firstErr
+}
\ No newline at end of file
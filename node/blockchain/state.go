@@ -0,0 +1,328 @@
+package blockchain
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+)
+
+const (
+    // stateKeyBits is the depth of the state tree, one level per bit of a fingerprint
+    stateKeyBits = 256
+    
+    // EmptyStateRoot is the state root of a chain without certifications
+    EmptyStateRoot = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// stateLeaf maps a public key fingerprint to the ID of its latest certification
+type stateLeaf struct {
+    key   [32]byte
+    value [32]byte
+}
+
+// stateNode is an immutable node of the sparse Merkle tree of certification
+// status. A subtree holding one certification is replaced by its leaf and an
+// empty subtree is nil, so the tree only branches where fingerprints differ.
+// Blocks share the nodes they did not change with their parent.
+type stateNode struct {
+    hash  [32]byte
+    leaf  *stateLeaf
+    left  *stateNode
+    right *stateNode
+}
+
+// StateProof proves whether a public key fingerprint is certified under a state
+// root. The siblings lead from the root down to the leaf of the key, to the leaf
+// of another key sharing the path, or to an empty subtree. The last two prove
+// that the key is not certified.
+type StateProof struct {
+    Key       string   `json:"key"`
+    LeafKey   string   `json:"leaf_key,omitempty"`
+    LeafValue string   `json:"leaf_value,omitempty"`
+    Siblings  []string `json:"siblings"`
+}
+
+// CertificationStatus proves whether a public key is certified at a main chain block
+type CertificationStatus struct {
+    BlockHash string      `json:"block_hash"`
+    Height    uint64      `json:"height"`
+    Header    BlockHeader `json:"header"`
+    Certified bool        `json:"certified"`
+    TxID      string      `json:"tx_id,omitempty"`
+    Proof     StateProof  `json:"proof"`
+}
+
+// stateLeafHash hashes a fingerprint and a certification ID into a leaf
+func stateLeafHash(key, value [32]byte) [32]byte {
+    data := make([]byte, 0, 1+len(key)+len(value))
+    data = append(data, merkleLeafPrefix)
+    data = append(data, key[:]...)
+    data = append(data, value[:]...)
+    
+    return sha256.Sum256(data)
+}
+
+// stateKeyBit returns the bit of a fingerprint that selects the child at depth
+func stateKeyBit(key [32]byte, depth int) byte {
+    return key[depth/8] >> (7 - depth%8) & 1
+}
+
+// decodeStateHash decodes a hex encoded 32 byte hash
+func decodeStateHash(s string) ([32]byte, error) {
+    var hash [32]byte
+    
+    data, err := hex.DecodeString(s)
+    if err != nil || len(data) != len(hash) {
+        return hash, fmt.Errorf("invalid hash %q", s)
+    }
+    
+    copy(hash[:], data)
+    return hash, nil
+}
+
+// nodeHash returns the hash of the subtree, an empty subtree hashes to zero
+func (n *stateNode) nodeHash() [32]byte {
+    if n == nil {
+        return [32]byte{}
+    }
+    
+    return n.hash
+}
+
+// root returns the hex encoded hash of the tree
+func (n *stateNode) root() string {
+    hash := n.nodeHash()
+    return hex.EncodeToString(hash[:])
+}
+
+// newStateLeaf creates a leaf node
+func newStateLeaf(key, value [32]byte) *stateNode {
+    return &stateNode{
+        hash: stateLeafHash(key, value),
+        leaf: &stateLeaf{key: key, value: value},
+    }
+}
+
+// newStateBranch creates an inner node from its children
+func newStateBranch(left, right *stateNode) *stateNode {
+    l, r := left.nodeHash(), right.nodeHash()
+    
+    n := &stateNode{left: left, right: right}
+    copy(n.hash[:], merkleNode(l[:], r[:]))
+    return n
+}
+
+// insert returns the subtree at depth with the key set to value
+func (n *stateNode) insert(key, value [32]byte, depth int) *stateNode {
+    switch {
+    case n == nil:
+        return newStateLeaf(key, value)
+    case n.leaf != nil && n.leaf.key == key:
+        return newStateLeaf(key, value)
+    case n.leaf != nil:
+        return joinStateLeaves(n, newStateLeaf(key, value), depth)
+    case stateKeyBit(key, depth) == 0:
+        return newStateBranch(n.left.insert(key, value, depth+1), n.right)
+    default:
+        return newStateBranch(n.left, n.right.insert(key, value, depth+1))
+    }
+}
+
+// joinStateLeaves builds the subtree at depth holding two leaves with different keys
+func joinStateLeaves(a, b *stateNode, depth int) *stateNode {
+    bitA, bitB := stateKeyBit(a.leaf.key, depth), stateKeyBit(b.leaf.key, depth)
+    
+    switch {
+    case bitA == bitB && bitA == 0:
+        return newStateBranch(joinStateLeaves(a, b, depth+1), nil)
+    case bitA == bitB:
+        return newStateBranch(nil, joinStateLeaves(a, b, depth+1))
+    case bitA == 0:
+        return newStateBranch(a, b)
+    default:
+        return newStateBranch(b, a)
+    }
+}
+
+// stateKey returns the fingerprint a public key is stored under in the state tree
+func stateKey(publicKey string) ([32]byte, error) {
+    fingerprint, err := crypto.GetPublicKeyFingerprint(publicKey)
+    if err != nil {
+        return [32]byte{}, fmt.Errorf("invalid public key: %w", err)
+    }
+    
+    return decodeStateHash(fingerprint)
+}
+
+// certify returns the state with the transaction as the latest certification of its public key
+func (n *stateNode) certify(tx *Transaction) (*stateNode, error) {
+    key, err := stateKey(tx.PublicKey)
+    if err != nil {
+        return nil, err
+    }
+    
+    value, err := decodeStateHash(tx.ID)
+    if err != nil {
+        return nil, fmt.Errorf("invalid transaction ID: %w", err)
+    }
+    
+    return n.insert(key, value, 0), nil
+}
+
+// applyCertifications returns the state after certifying the transactions in order
+func applyCertifications(n *stateNode, transactions []*Transaction) (*stateNode, error) {
+    for _, tx := range transactions {
+        next, err := n.certify(tx)
+        if err != nil {
+            return nil, fmt.Errorf("transaction %s: %w", tx.ID, err)
+        }
+        n = next
+    }
+    
+    return n, nil
+}
+
+// prove builds the proof of a fingerprint from the root of the tree
+func (n *stateNode) prove(key [32]byte) *StateProof {
+    proof := &StateProof{Key: hex.EncodeToString(key[:]), Siblings: []string{}}
+    
+    for depth := 0; n != nil && n.leaf == nil; depth++ {
+        var sibling [32]byte
+        if stateKeyBit(key, depth) == 0 {
+            sibling = n.right.nodeHash()
+            n = n.left
+        } else {
+            sibling = n.left.nodeHash()
+            n = n.right
+        }
+        proof.Siblings = append(proof.Siblings, hex.EncodeToString(sibling[:]))
+    }
+    
+    if n != nil {
+        proof.LeafKey = hex.EncodeToString(n.leaf.key[:])
+        proof.LeafValue = hex.EncodeToString(n.leaf.value[:])
+    }
+    
+    return proof
+}
+
+// Verify checks the proof against a state root and returns the ID of the
+// certification of the key, or an empty string when the key is not certified
+func (p *StateProof) Verify(root string) (string, error) {
+    key, err := decodeStateHash(p.Key)
+    if err != nil {
+        return "", fmt.Errorf("invalid key: %w", err)
+    }
+    if len(p.Siblings) > stateKeyBits {
+        return "", fmt.Errorf("proof has %d siblings, the tree is %d levels deep", len(p.Siblings), stateKeyBits)
+    }
+    
+    var hash [32]byte
+    certified := false
+    
+    if p.LeafKey != "" {
+        leafKey, err := decodeStateHash(p.LeafKey)
+        if err != nil {
+            return "", fmt.Errorf("invalid leaf key: %w", err)
+        }
+        leafValue, err := decodeStateHash(p.LeafValue)
+        if err != nil {
+            return "", fmt.Errorf("invalid leaf value: %w", err)
+        }
+        
+        // Another key's leaf only proves absence when it sits on the path of the key
+        for depth := range p.Siblings {
+            if stateKeyBit(leafKey, depth) != stateKeyBit(key, depth) {
+                return "", fmt.Errorf("leaf %s is not on the path of key %s", p.LeafKey, p.Key)
+            }
+        }
+        
+        hash = stateLeafHash(leafKey, leafValue)
+        certified = leafKey == key
+    } else if p.LeafValue != "" {
+        return "", fmt.Errorf("proof has a leaf value without a leaf key")
+    }
+    
+    for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+        sibling, err := decodeStateHash(p.Siblings[depth])
+        if err != nil {
+            return "", fmt.Errorf("invalid sibling: %w", err)
+        }
+        
+        if stateKeyBit(key, depth) == 0 {
+            copy(hash[:], merkleNode(hash[:], sibling[:]))
+        } else {
+            copy(hash[:], merkleNode(sibling[:], hash[:]))
+        }
+    }
+    
+    expected, err := decodeStateHash(root)
+    if err != nil {
+        return "", fmt.Errorf("invalid state root: %w", err)
+    }
+    if !bytes.Equal(hash[:], expected[:]) {
+        return "", fmt.Errorf("proof does not lead to state root %s", root)
+    }
+    
+    if !certified {
+        return "", nil
+    }
+    return p.LeafValue, nil
+}
+
+// Verify checks that the header matches the block hash and height and that the
+// proof and the reported status agree with the header's state root
+func (s *CertificationStatus) Verify() error {
+    if hash := s.Header.Hash(); hash != s.BlockHash {
+        return fmt.Errorf("header hashes to %s, expected block %s", hash, s.BlockHash)
+    }
+    if s.Header.Height != s.Height {
+        return fmt.Errorf("header is at height %d, expected %d", s.Header.Height, s.Height)
+    }
+    
+    txID, err := s.Proof.Verify(s.Header.StateRoot)
+    if err != nil {
+        return err
+    }
+    
+    if s.Certified != (txID != "") || s.TxID != txID {
+        return fmt.Errorf("proof does not match the reported status")
+    }
+    
+    return nil
+}
+
+// GetCertificationStatus proves whether the public key with the given fingerprint
+// is certified in the state of the main chain block at a height
+func (bc *Blockchain) GetCertificationStatus(fingerprint string, height uint64) (*CertificationStatus, error) {
+    key, err := decodeStateHash(fingerprint)
+    if err != nil {
+        return nil, fmt.Errorf("invalid fingerprint: %w", err)
+    }
+    
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+    
+    if height >= uint64(len(bc.blocks)) {
+        return nil, fmt.Errorf("block not found")
+    }
+    
+    node := bc.index[bc.blocks[height].Hash()]
+    proof := node.state.prove(key)
+    
+    status := &CertificationStatus{
+        BlockHash: node.hash,
+        Height:    node.height,
+        Header:    node.block.Header,
+        Proof:     *proof,
+    }
+    if proof.LeafKey == proof.Key {
+        status.Certified = true
+        status.TxID = proof.LeafValue
+    }
+    
+    return status, nil
+}
\ No newline at end of file
@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+    "encoding/hex"
+    "strings"
+    "testing"
+)
+
+// testStateKey returns a 32 byte key starting with the given bytes
+func testStateKey(prefix ...byte) [32]byte {
+    var key [32]byte
+    copy(key[:], prefix)
+    return key
+}
+
+// testStateValue returns a 32 byte value filled with b
+func testStateValue(b byte) [32]byte {
+    var value [32]byte
+    for i := range value {
+        value[i] = b
+    }
+    return value
+}
+
+// testStateTree returns a tree with keys branching at the first, second and last
+// of their leading eight bits
+func testStateTree() (*stateNode, map[[32]byte][32]byte) {
+    entries := map[[32]byte][32]byte{
+        testStateKey(0x00): testStateValue(0x11),
+        testStateKey(0x01): testStateValue(0x22),
+        testStateKey(0x40): testStateValue(0x33),
+        testStateKey(0x80): testStateValue(0x44),
+    }
+    
+    var tree *stateNode
+    for key, value := range entries {
+        tree = tree.insert(key, value, 0)
+    }
+    
+    return tree, entries
+}
+
+func TestStateProofMembership(t *testing.T) {
+    tree, entries := testStateTree()
+    root := tree.root()
+    
+    for key, value := range entries {
+        got, err := tree.prove(key).Verify(root)
+        if err != nil {
+            t.Fatalf("key %x: %v", key[:1], err)
+        }
+        if want := hex.EncodeToString(value[:]); got != want {
+            t.Fatalf("key %x: proved value %s, want %s", key[:1], got, want)
+        }
+    }
+}
+
+func TestStateProofNonMembership(t *testing.T) {
+    tree, _ := testStateTree()
+    
+    tests := []struct {
+        name     string
+        tree     *stateNode
+        key      [32]byte
+        wantLeaf bool
+    }{
+        {"empty tree", nil, testStateKey(0x00), false},
+        {"path ends at another key", tree, testStateKey(0xc0), true},
+        {"path ends at an empty subtree", tree, testStateKey(0x20), false},
+        {"empty subtree deep in a shared prefix", tree, testStateKey(0x02), false},
+        {"differs only after the first byte", tree, testStateKey(0x80, 0x01), true},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            proof := tt.tree.prove(tt.key)
+            if (proof.LeafKey != "") != tt.wantLeaf {
+                t.Fatalf("proof leaf key %q, want a leaf %v", proof.LeafKey, tt.wantLeaf)
+            }
+            
+            got, err := proof.Verify(tt.tree.root())
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != "" {
+                t.Fatalf("absent key proved certified by %s", got)
+            }
+        })
+    }
+    
+    if root := (*stateNode)(nil).root(); root != EmptyStateRoot {
+        t.Fatalf("empty tree root = %s, want %s", root, EmptyStateRoot)
+    }
+}
+
+func TestStateProofRejectsForgery(t *testing.T) {
+    tree, _ := testStateTree()
+    root := tree.root()
+    
+    member := testStateKey(0x01)
+    other := hex.EncodeToString(member[:])
+    
+    tests := []struct {
+        name  string
+        key   [32]byte
+        forge func(p *StateProof)
+        root  string
+    }{
+        {"member claimed absent", member, func(p *StateProof) {
+            p.LeafKey, p.LeafValue = "", ""
+        }, root},
+        {"other value", member, func(p *StateProof) {
+            p.LeafValue = strings.Repeat("55", 32)
+        }, root},
+        {"changed sibling", member, func(p *StateProof) {
+            p.Siblings[len(p.Siblings)-1] = strings.Repeat("66", 32)
+        }, root},
+        {"missing sibling", member, func(p *StateProof) {
+            p.Siblings = p.Siblings[:len(p.Siblings)-1]
+        }, root},
+        {"leaf off the path", testStateKey(0xc0), func(p *StateProof) {
+            p.LeafKey, p.LeafValue = other, strings.Repeat("22", 32)
+        }, root},
+        {"leaf value without a key", testStateKey(0x20), func(p *StateProof) {
+            p.LeafValue = strings.Repeat("22", 32)
+        }, root},
+        {"deeper than the tree", testStateKey(0x20), func(p *StateProof) {
+            for len(p.Siblings) <= stateKeyBits {
+                p.Siblings = append(p.Siblings, EmptyStateRoot)
+            }
+        }, root},
+        {"malformed key", member, func(p *StateProof) {
+            p.Key = "zz"
+        }, root},
+        {"other root", member, func(p *StateProof) {}, strings.Repeat("77", 32)},
+        {"malformed root", member, func(p *StateProof) {}, "root"},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            proof := tree.prove(tt.key)
+            tt.forge(proof)
+            
+            if got, err := proof.Verify(tt.root); err == nil {
+                t.Fatalf("forged proof verified with value %q", got)
+            }
+        })
+    }
+}
+
+func TestStateTreeUpdates(t *testing.T) {
+    tree, entries := testStateTree()
+    
+    // The root depends only on the contents, not on the insertion order
+    keys := [][32]byte{testStateKey(0x80), testStateKey(0x40), testStateKey(0x01), testStateKey(0x00)}
+    var reordered *stateNode
+    for _, key := range keys {
+        reordered = reordered.insert(key, entries[key], 0)
+    }
+    if reordered.root() != tree.root() {
+        t.Fatal("insertion order changed the root")
+    }
+    
+    // Replacing a value changes the root and leaves the old tree intact
+    key, value := testStateKey(0x40), testStateValue(0x99)
+    updated := tree.insert(key, value, 0)
+    if updated.root() == tree.root() {
+        t.Fatal("new value kept the root")
+    }
+    
+    got, err := updated.prove(key).Verify(updated.root())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got != hex.EncodeToString(value[:]) {
+        t.Fatalf("proved value %s after update", got)
+    }
+    
+    old := entries[key]
+    if got, err := tree.prove(key).Verify(tree.root()); err != nil || got != hex.EncodeToString(old[:]) {
+        t.Fatalf("old tree proves %q, %v", got, err)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package blockchain
+
+import (
+    "time"
+)
+
+// policyCheckInterval is how often the production policy is evaluated
+const policyCheckInterval = time.Second
+
+// buildBlockTemplate builds an unsealed block on the current tip. Pool transactions
+// are taken in arrival order until Mining.MaxTransPerBlock or Blockchain.MaxBlockSize
+// is reached; transactions that do not fit in the remaining space are skipped.
+func (bc *Blockchain) buildBlockTemplate() (*Block, *blockNode) {
+    bc.mu.RLock()
+    parent := bc.tip
+    bits := bc.calcNextBits(parent)
+    bc.mu.RUnlock()
+    
+    // The timestamp must be after the median time past of the parent
+    timestamp := bc.AdjustedTime().Truncate(time.Second)
+    if mtp := medianTimePast(parent, &parent.block.Header); !timestamp.After(mtp) {
+        timestamp = mtp.Add(time.Second)
+    }
+    
+    block := NewBlock(nil, parent.hash, parent.height+1)
+    block.Header.Timestamp = timestamp
+    block.Header.Bits = bits
+    
+    maxCount := bc.config.Mining.MaxTransPerBlock
+    maxSize := bc.config.Blockchain.MaxBlockSize
+    
+    size := 0
+    if data, err := block.Serialize(); err == nil {
+        size = len(data)
+    }
+    
+    state := parent.state
+    
+    for _, tx := range bc.GetMiningPool() {
+        if len(block.Transactions) >= maxCount {
+            break
+        }
+        
+        data, err := tx.Serialize()
+        if err != nil {
+            bc.logger.Warn("Skipping transaction %s: %v", tx.ID, err)
+            continue
+        }
+        
+        if size+len(data) > maxSize {
+            continue
+        }
+        
+        next, err := state.certify(tx)
+        if err != nil {
+            bc.logger.Warn("Skipping transaction %s: %v", tx.ID, err)
+            continue
+        }
+        
+        block.Transactions = append(block.Transactions, tx)
+        size += len(data)
+        state = next
+    }
+    
+    // Every transaction was encoded above, so the root cannot fail
+    block.Header.MerkleRoot, _ = block.CalculateMerkleRoot()
+    block.Header.StateRoot = state.root()
+    
+    return block, parent
+}
+
+// shouldProduceBlock applies the block production policy: mine once Mining.MinBatchSize
+// transactions are pending, once the oldest pending transaction has waited Mining.MaxWait,
+// or, with Mining.MineEmptyBlocks, once Blockchain.BlockTime has passed since the tip
+func (bc *Blockchain) shouldProduceBlock(now time.Time) bool {
+    pending, oldest := bc.poolStats()
+    
+    if pending > 0 {
+        return pending >= bc.config.Mining.MinBatchSize || now.Sub(oldest) >= bc.config.Mining.MaxWait
+    }
+    
+    if !bc.config.Mining.MineEmptyBlocks {
+        return false
+    }
+    
+    bc.mu.RLock()
+    tipTime := bc.tip.block.Header.Timestamp
+    bc.mu.RUnlock()
+    
+    return now.Sub(tipTime) >= bc.config.Blockchain.BlockTime
+}
+
+// poolStats returns the number of pending transactions and the arrival time of the oldest one
+func (bc *Blockchain) poolStats() (int, time.Time) {
+    stats := bc.pool.Stats()
+    return stats.Count, stats.Oldest
+}
\ No newline at end of file
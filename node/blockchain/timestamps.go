@@ -0,0 +1,155 @@
+package blockchain
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+)
+
+const (
+    // medianTimeBlocks is the number of previous blocks used for the median time past
+    medianTimeBlocks = 11
+    
+    // maxFutureBlockTime is how far a block timestamp may be ahead of the network-adjusted time
+    maxFutureBlockTime = 2 * time.Hour
+    
+    // maxTimeSamples is the number of peer time samples kept
+    maxTimeSamples = 200
+    
+    // minTimeSamples is the number of peer samples needed before the clock is adjusted
+    minTimeSamples = 5
+    
+    // maxTimeOffset is the largest offset from the local clock that is applied
+    maxTimeOffset = 70 * time.Minute
+)
+
+// medianTimePast returns the median timestamp of the last medianTimeBlocks blocks ending at parent
+func medianTimePast(chain consensus.ChainReader, parent *BlockHeader) time.Time {
+    timestamps := make([]int64, 0, medianTimeBlocks)
+    
+    header := parent
+    for i := 0; i < medianTimeBlocks && header != nil; i++ {
+        timestamps = append(timestamps, header.Timestamp.Unix())
+        if header.Height == 0 {
+            break
+        }
+        header = chain.GetAncestor(header.Height - 1)
+    }
+    
+    sort.Slice(timestamps, func(i, j int) bool {
+        return timestamps[i] < timestamps[j]
+    })
+    
+    return time.Unix(timestamps[len(timestamps)/2], 0)
+}
+
+// checkTimestamp checks a block timestamp against its parent and the network-adjusted time
+func (bc *Blockchain) checkTimestamp(chain consensus.ChainReader, header *BlockHeader) error {
+    return CheckHeaderTimestamp(chain, header, bc.AdjustedTime())
+}
+
+// CheckHeaderTimestamp checks that a header is newer than the median time past of
+// its parent and not too far ahead of now
+func CheckHeaderTimestamp(chain consensus.ChainReader, header *BlockHeader, now time.Time) error {
+    parent := chain.GetAncestor(header.Height - 1)
+    if parent == nil {
+        return fmt.Errorf("unknown parent of block %d", header.Height)
+    }
+    
+    if mtp := medianTimePast(chain, parent); header.Timestamp.Unix() <= mtp.Unix() {
+        return fmt.Errorf("block timestamp %s is not after the median time past %s",
+            header.Timestamp.Format(time.RFC3339), mtp.Format(time.RFC3339))
+    }
+    
+    return checkFutureTime(header, now)
+}
+
+// checkFutureTimestamp checks that a block timestamp is not too far in the future
+func (bc *Blockchain) checkFutureTimestamp(header *BlockHeader) error {
+    return checkFutureTime(header, bc.AdjustedTime())
+}
+
+// checkFutureTime checks that a header timestamp is at most maxFutureBlockTime ahead of now
+func checkFutureTime(header *BlockHeader, now time.Time) error {
+    if limit := now.Add(maxFutureBlockTime); header.Timestamp.After(limit) {
+        return fmt.Errorf("block timestamp %s is too far in the future", header.Timestamp.Format(time.RFC3339))
+    }
+    
+    return nil
+}
+
+// medianTime is a clock adjusted by the median offset of the times reported by peers
+type medianTime struct {
+    mu      sync.Mutex
+    samples map[string]time.Duration
+    order   []string
+    offset  time.Duration
+}
+
+// newMedianTime creates a clock without peer samples
+func newMedianTime() *medianTime {
+    return &medianTime{
+        samples: make(map[string]time.Duration),
+    }
+}
+
+// add records the time reported by a peer and returns the resulting offset
+func (m *medianTime) add(peer string, peerTime time.Time) (time.Duration, bool) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    
+    if _, ok := m.samples[peer]; !ok {
+        m.order = append(m.order, peer)
+        if len(m.order) > maxTimeSamples {
+            delete(m.samples, m.order[0])
+            m.order = m.order[1:]
+        }
+    }
+    m.samples[peer] = time.Until(peerTime).Truncate(time.Second)
+    
+    if len(m.samples) < minTimeSamples {
+        return m.offset, true
+    }
+    
+    offsets := make([]time.Duration, 0, len(m.samples))
+    for _, offset := range m.samples {
+        offsets = append(offsets, offset)
+    }
+    sort.Slice(offsets, func(i, j int) bool {
+        return offsets[i] < offsets[j]
+    })
+    
+    // Ignore the peers entirely when they disagree too much with the local clock
+    median := offsets[len(offsets)/2]
+    if median > maxTimeOffset || median < -maxTimeOffset {
+        m.offset = 0
+        return median, false
+    }
+    
+    m.offset = median
+    return m.offset, true
+}
+
+// now returns the network-adjusted time
+func (m *medianTime) now() time.Time {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    
+    return time.Now().Add(m.offset)
+}
+
+// AddPeerTime records the current time reported by a peer for the network-adjusted clock
+func (bc *Blockchain) AddPeerTime(peer string, peerTime time.Time) {
+    offset, ok := bc.clock.add(peer, peerTime)
+    if !ok {
+        bc.logger.Warn("Peer clocks are %s off the local clock, check the system time", offset)
+    }
+}
+
+// AdjustedTime returns the local time adjusted by the median offset reported by peers
+func (bc *Blockchain) AdjustedTime() time.Time {
+    return bc.clock.now()
+}
\ No newline at end of file
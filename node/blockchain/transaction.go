@@ -10,6 +10,7 @@ import (
     "time"
     
     "github.com/CertificationAgencyBlockchain/node/crypto"
+    "github.com/CertificationAgencyBlockchain/node/utils"
 )
 
 // Transaction represents a certification transaction
@@ -31,7 +32,7 @@ func NewTransaction(publicKey, name, surname, inquiryID string, datetime time.Ti
         Name:      name,
         Surname:   surname,
         InquiryID: inquiryID,
-        Datetime:  datetime,
+        Datetime:  datetime.Truncate(time.Second),
         Signature: signature,
         Status:    "pending",
     }
@@ -40,21 +41,25 @@ func NewTransaction(publicKey, name, surname, inquiryID string, datetime time.Ti
     return tx
 }
 
-// Hash calculates the hash of the transaction
+// Hash calculates the hash of the transaction. Fields are length prefixed so that
+// moving bytes from one field to the next changes the hash.
 func (tx *Transaction) Hash() string {
     var buf bytes.Buffer
     
-    buf.WriteString(tx.PublicKey)
-    buf.WriteString(tx.Name)
-    buf.WriteString(tx.Surname)
-    buf.WriteString(tx.InquiryID)
+    for _, field := range []string{tx.PublicKey, tx.Name, tx.Surname, tx.InquiryID} {
+        binary.Write(&buf, binary.BigEndian, uint32(len(field)))
+        buf.WriteString(field)
+    }
     binary.Write(&buf, binary.BigEndian, tx.Datetime.Unix())
     
     hash := sha256.Sum256(buf.Bytes())
     return hex.EncodeToString(hash[:])
 }
 
-// Validate validates the transaction
+// maxTxFutureDrift is how far a transaction datetime may be ahead of the reference time
+const maxTxFutureDrift = 5 * time.Minute
+
+// Validate checks the structure of the transaction without depending on the current time
 func (tx *Transaction) Validate() error {
     // Check required fields
     if tx.PublicKey == "" {
@@ -73,14 +78,30 @@ func (tx *Transaction) Validate() error {
         return fmt.Errorf("signature is required")
     }
     
-    // Validate datetime is not too old (24 hours)
-    if time.Since(tx.Datetime) > 24*time.Hour {
-        return fmt.Errorf("transaction datetime is too old")
+    if tx.Datetime.IsZero() {
+        return fmt.Errorf("datetime is required")
+    }
+    
+    if tx.ID != tx.Hash() {
+        return fmt.Errorf("transaction ID does not match its hash")
+    }
+    
+    return nil
+}
+
+// ValidateFreshness checks that a transaction submitted at now is recent enough for the mining pool
+func (tx *Transaction) ValidateFreshness(now time.Time, maxAge time.Duration) error {
+    if err := utils.ValidateAge(tx.Datetime, now, maxAge, maxTxFutureDrift); err != nil {
+        return fmt.Errorf("transaction datetime is not fresh: %w", err)
     }
     
-    // Validate datetime is not in the future
-    if tx.Datetime.After(time.Now().Add(5 * time.Minute)) {
-        return fmt.Errorf("transaction datetime is in the future")
+    return nil
+}
+
+// ValidateInBlock checks the transaction datetime against the timestamp of the block that includes it
+func (tx *Transaction) ValidateInBlock(blockTime time.Time, maxAge time.Duration) error {
+    if err := utils.ValidateAge(tx.Datetime, blockTime, maxAge, maxTxFutureDrift); err != nil {
+        return fmt.Errorf("transaction datetime does not fit the block: %w", err)
     }
     
     return nil
@@ -106,113 +127,26 @@ func (tx *Transaction) GetSignableMessage() string {
     )
 }
 
-// Serialize serializes the transaction to bytes
+// Serialize encodes the transaction in the canonical binary format
 func (tx *Transaction) Serialize() ([]byte, error) {
-    var buf bytes.Buffer
-    
-    // Write fields with length prefixes
-    writeString := func(s string) error {
-        if err := binary.Write(&buf, binary.BigEndian, uint32(len(s))); err != nil {
-            return err
-        }
-        _, err := buf.WriteString(s)
-        return err
-    }
-    
-    if err := writeString(tx.ID); err != nil {
-        return nil, err
-    }
-    
-    if err := writeString(tx.PublicKey); err != nil {
-        return nil, err
-    }
-    
-    if err := writeString(tx.Name); err != nil {
-        return nil, err
-    }
-    
-    if err := writeString(tx.Surname); err != nil {
-        return nil, err
-    }
-    
-    if err := writeString(tx.InquiryID); err != nil {
-        return nil, err
-    }
-    
-    if err := binary.Write(&buf, binary.BigEndian, tx.Datetime.Unix()); err != nil {
-        return nil, err
-    }
-    
-    if err := writeString(tx.Signature); err != nil {
-        return nil, err
-    }
+    e := &encoder{}
+    e.transaction(tx)
     
-    if err := writeString(tx.Status); err != nil {
-        return nil, err
-    }
-    
-    return buf.Bytes(), nil
+    return e.buf, e.err
 }
 
-// Deserialize deserializes a transaction from bytes
+// DeserializeTransaction decodes a transaction from its canonical binary encoding
 func DeserializeTransaction(data []byte) (*Transaction, error) {
-    buf := bytes.NewReader(data)
-    tx := &Transaction{}
-    
-    readString := func() (string, error) {
-        var length uint32
-        if err := binary.Read(buf, binary.BigEndian, &length); err != nil {
-            return "", err
-        }
-        
-        strBytes := make([]byte, length)
-        if _, err := buf.Read(strBytes); err != nil {
-            return "", err
-        }
-        
-        return string(strBytes), nil
-    }
-    
-    var err error
-    
-    if tx.ID, err = readString(); err != nil {
-        return nil, err
-    }
-    
-    if tx.PublicKey, err = readString(); err != nil {
-        return nil, err
-    }
-    
-    if tx.Name, err = readString(); err != nil {
-        return nil, err
-    }
-    
-    if tx.Surname, err = readString(); err != nil {
-        return nil, err
-    }
-    
-    if tx.InquiryID, err = readString(); err != nil {
-        return nil, err
-    }
-    
-    var timestamp int64
-    if err := binary.Read(buf, binary.BigEndian, &timestamp); err != nil {
-        return nil, err
-    }
-    tx.Datetime = time.Unix(timestamp, 0)
-    
-    if tx.Signature, err = readString(); err != nil {
-        return nil, err
-    }
-    
-    if tx.Status, err = readString(); err != nil {
+    d := &decoder{data: data}
+    tx := d.transaction()
+    if err := d.finish(); err != nil {
         return nil, err
     }
     
     return tx, nil
 }
 
-// ToJSON converts the transaction to JSON
+// ToJSON converts the transaction to JSON for the REST API
 func (tx *Transaction) ToJSON() ([]byte, error) {
     return json.Marshal(tx)
 }
@@ -0,0 +1,197 @@
+package bundle
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "math/big"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+)
+
+const (
+    // Version is the version of the bundle format written by Build
+    Version = 1
+    
+    // maxHeadersPerBatch is the number of headers read from the chain at a time
+    maxHeadersPerBatch = 2000
+)
+
+// Bundle proves offline that a certification is part of a block, and that the
+// block is buried under a chain of headers ending at a checkpoint
+type Bundle struct {
+    Version int                           `json:"version"`
+    Network string                        `json:"network"`
+    Proof   blockchain.CertificationProof `json:"proof"`
+    
+    // Headers are the headers after the certification block up to and
+    // including the checkpoint
+    Headers []blockchain.BlockHeader `json:"headers"`
+}
+
+// Result describes a certification proven by a bundle
+type Result struct {
+    TxID             string
+    PublicKey        string
+    Fingerprint      string
+    Name             string
+    Surname          string
+    InquiryID        string
+    Datetime         time.Time
+    BlockHash        string
+    Height           uint64
+    Checkpoint       string
+    CheckpointHeight uint64
+    Confirmations    uint64
+    Work             *big.Int
+}
+
+// Build creates the bundle of a main chain certification with the headers up to
+// the checkpoint height. A zero checkpoint height uses the chain tip.
+func Build(bc *blockchain.Blockchain, txID string, checkpointHeight uint64) (*Bundle, error) {
+    proof, err := bc.GetCertificationProof(txID)
+    if err != nil {
+        return nil, err
+    }
+    
+    if checkpointHeight == 0 {
+        checkpointHeight = bc.GetHeight()
+    }
+    if checkpointHeight < proof.Height {
+        return nil, fmt.Errorf("checkpoint %d is below the certification block %d", checkpointHeight, proof.Height)
+    }
+    
+    b := &Bundle{
+        Version: Version,
+        Network: bc.Params().Name,
+        Proof:   *proof,
+        Headers: []blockchain.BlockHeader{},
+    }
+    
+    for from := proof.Height + 1; from <= checkpointHeight; {
+        count := checkpointHeight - from + 1
+        if count > maxHeadersPerBatch {
+            count = maxHeadersPerBatch
+        }
+        
+        headers := bc.GetHeaders(from, int(count))
+        if len(headers) == 0 {
+            return nil, fmt.Errorf("checkpoint %d is above the chain tip", checkpointHeight)
+        }
+        
+        b.Headers = append(b.Headers, headers...)
+        from += uint64(len(headers))
+    }
+    
+    // The local main chain is the checkpoint, a reorganization while reading
+    // headers leaves a segment that does not end at its current block
+    checkpoint, err := bc.GetBlock(checkpointHeight)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := b.Verify(bc.Params(), bc.Engine(), checkpoint.Hash()); err != nil {
+        return nil, fmt.Errorf("chain changed while building the bundle: %w", err)
+    }
+    
+    return b, nil
+}
+
+// Verify checks the bundle without network access. The transaction must match its
+// ID and signature, the Merkle proof must lead to the block header, and the headers
+// must link up to the checkpoint with valid seals. Seals are checked on their own,
+// without the history that sets their difficulty or signers, so the segment is only
+// as trustworthy as its checkpoint, and the last header must match its hash.
+func (b *Bundle) Verify(params *chainparams.Params, engine consensus.Engine, checkpoint string) (*Result, error) {
+    if b.Version != Version {
+        return nil, fmt.Errorf("unsupported bundle version %d", b.Version)
+    }
+    if b.Network != params.Name {
+        return nil, fmt.Errorf("bundle is for network %s, expected %s", b.Network, params.Name)
+    }
+    
+    if err := b.Proof.Verify(); err != nil {
+        return nil, fmt.Errorf("invalid inclusion proof: %w", err)
+    }
+    
+    tx := b.Proof.Transaction
+    if err := tx.Validate(); err != nil {
+        return nil, fmt.Errorf("invalid certification: %w", err)
+    }
+    if err := tx.VerifySignature(); err != nil {
+        return nil, fmt.Errorf("invalid certification signature: %w", err)
+    }
+    
+    fingerprint, err := crypto.GetPublicKeyFingerprint(tx.PublicKey)
+    if err != nil {
+        return nil, fmt.Errorf("invalid public key: %w", err)
+    }
+    
+    last := b.Proof.Header
+    if err := engine.VerifySeal(nil, &last); err != nil {
+        return nil, fmt.Errorf("block %d has an invalid seal: %w", last.Height, err)
+    }
+    work := new(big.Int).Set(engine.CalcWork(last.Bits))
+    
+    for i := range b.Headers {
+        header := b.Headers[i]
+        
+        if header.Height != last.Height+1 {
+            return nil, fmt.Errorf("header %d does not follow height %d", header.Height, last.Height)
+        }
+        if header.PrevBlockHash != last.Hash() {
+            return nil, fmt.Errorf("header %d does not link to its parent", header.Height)
+        }
+        if err := engine.VerifySeal(nil, &header); err != nil {
+            return nil, fmt.Errorf("header %d has an invalid seal: %w", header.Height, err)
+        }
+        
+        work.Add(work, engine.CalcWork(header.Bits))
+        last = header
+    }
+    
+    if checkpoint == "" {
+        return nil, fmt.Errorf("no trusted checkpoint for height %d of %s", last.Height, params.Name)
+    }
+    
+    lastHash := last.Hash()
+    if lastHash != checkpoint {
+        return nil, fmt.Errorf("bundle ends at block %s, expected checkpoint %s", lastHash, checkpoint)
+    }
+    
+    return &Result{
+        TxID:             tx.ID,
+        PublicKey:        tx.PublicKey,
+        Fingerprint:      fingerprint,
+        Name:             tx.Name,
+        Surname:          tx.Surname,
+        InquiryID:        tx.InquiryID,
+        Datetime:         tx.Datetime,
+        BlockHash:        b.Proof.BlockHash,
+        Height:           b.Proof.Height,
+        Checkpoint:       lastHash,
+        CheckpointHeight: last.Height,
+        Confirmations:    last.Height - b.Proof.Height + 1,
+        Work:             work,
+    }, nil
+}
+
+// Write writes the bundle as indented JSON
+func (b *Bundle) Write(w io.Writer) error {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(b)
+}
+
+// Read reads a bundle written by Write
+func Read(r io.Reader) (*Bundle, error) {
+    var b Bundle
+    if err := json.NewDecoder(r).Decode(&b); err != nil {
+        return nil, fmt.Errorf("failed to decode bundle: %w", err)
+    }
+    
+    return &b, nil
+}
\ No newline at end of file
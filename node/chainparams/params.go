@@ -0,0 +1,78 @@
+package chainparams
+
+import (
+    "fmt"
+    "time"
+)
+
+// Params holds the parameters that every node of a network must agree on
+type Params struct {
+    Name              string
+    MagicValue        uint32
+    Flag              string
+    Port              int
+    DiscoveryPort     int
+    InitialDifficulty int
+    
+    // OnDemandMining turns off background mining; blocks are only generated on request
+    OnDemandMining    bool
+    
+    // Genesis block contents and the hash they must produce
+    GenesisTime       time.Time
+    GenesisBits       uint32
+    GenesisHash       string
+}
+
+// MainNet is the production certification network
+var MainNet = Params{
+    Name:              "mainnet",
+    MagicValue:        0xD9B4BEF9,
+    Flag:              "CERTIFICATION-BLOCKCHAIN-CLS",
+    Port:              8333,
+    DiscoveryPort:     45678,
+    InitialDifficulty: 16,
+    GenesisTime:       time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+    GenesisBits:       0x1f00ffff,
+    GenesisHash:       "76c939c2c13154416188c5e24eeaa9916211af5725069c0c98e5aa807e6839fb",
+}
+
+// TestNet is the public test network
+var TestNet = Params{
+    Name:              "testnet",
+    MagicValue:        0x0B110907,
+    Flag:              "CERTIFICATION-BLOCKCHAIN-TEST",
+    Port:              18333,
+    DiscoveryPort:     45679,
+    InitialDifficulty: 12,
+    GenesisTime:       time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+    GenesisBits:       0x1f0fffff,
+    GenesisHash:       "d6284a629fb3c812bb5596bc3ffae8e879c8337e17a7d401f3640ffe5d754032",
+}
+
+// RegTest is a local network for development and tests with a trivial difficulty
+var RegTest = Params{
+    Name:              "regtest",
+    MagicValue:        0xFABFB5DA,
+    Flag:              "CERTIFICATION-BLOCKCHAIN-REGTEST",
+    Port:              18444,
+    DiscoveryPort:     45680,
+    InitialDifficulty: 1,
+    OnDemandMining:    true,
+    GenesisTime:       time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+    GenesisBits:       0x207fffff,
+    GenesisHash:       "ffb273c06e07ee0cd43d71c59a910e10c0d40da88d92b5ec45912f39f678165b",
+}
+
+// ForNetwork returns the parameters of a network by name
+func ForNetwork(name string) (*Params, error) {
+    switch name {
+    case MainNet.Name:
+        return &MainNet, nil
+    case TestNet.Name:
+        return &TestNet, nil
+    case RegTest.Name:
+        return &RegTest, nil
+    default:
+        return nil, fmt.Errorf("unknown network: %s", name)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,219 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/bundle"
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/config"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+    "github.com/CertificationAgencyBlockchain/node/storage"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+// commandOptions holds the flags of the offline commands that work on the chain
+type commandOptions struct {
+    file   string
+    txID   string
+    height uint64
+}
+
+// runCommand runs an offline maintenance command and reports whether args named one
+func runCommand(args []string) bool {
+    if len(args) == 0 {
+        return false
+    }
+    
+    var run func(*blockchain.Blockchain, *commandOptions) error
+    switch args[0] {
+    case "export-chain":
+        run = exportChain
+    case "import-chain":
+        run = importChain
+    case "export-bundle":
+        run = exportBundle
+    case "verify-bundle":
+        verifyBundle(args[0], args[1:])
+        return true
+    default:
+        return false
+    }
+    
+    fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+    configPath := fs.String("config", "config/config.yaml", "Path to configuration file")
+    dataDir := fs.String("data", "./data", "Data directory")
+    file := fs.String("file", "", "Bootstrap or bundle file to write or read")
+    txID := fs.String("txid", "", "Certification to export in a bundle")
+    height := fs.Uint64("height", 0, "Checkpoint height a bundle extends to, 0 for the chain tip")
+    debug := fs.Bool("debug", false, "Enable debug logging")
+    fs.Parse(args[1:])
+    
+    logger := utils.NewLogger(*debug)
+    
+    if *file == "" {
+        logger.Fatal("%s needs a -file", args[0])
+    }
+    if args[0] == "export-bundle" && *txID == "" {
+        logger.Fatal("%s needs a -txid", args[0])
+    }
+    
+    cfg, err := config.LoadConfig(*configPath)
+    if err != nil {
+        logger.Fatal("Failed to load configuration: %v", err)
+    }
+    if *dataDir != "./data" {
+        cfg.Storage.DataDir = *dataDir
+    }
+    
+    db, err := storage.NewDatabase(cfg.Storage.DataDir)
+    if err != nil {
+        logger.Fatal("Failed to initialize database: %v", err)
+    }
+    defer db.Close()
+    
+    bc, err := blockchain.NewBlockchain(cfg, db, logger)
+    if err != nil {
+        logger.Fatal("Failed to initialize blockchain: %v", err)
+    }
+    
+    opts := &commandOptions{file: *file, txID: *txID, height: *height}
+    if err := run(bc, opts); err != nil {
+        logger.Error("%s failed: %v", args[0], err)
+        db.Close()
+        os.Exit(1)
+    }
+    
+    return true
+}
+
+// exportChain writes the main chain to a bootstrap file
+func exportChain(bc *blockchain.Blockchain, opts *commandOptions) error {
+    f, err := os.Create(opts.file)
+    if err != nil {
+        return err
+    }
+    
+    n, err := bc.ExportChain(f)
+    if err != nil {
+        f.Close()
+        return err
+    }
+    if err := f.Close(); err != nil {
+        return err
+    }
+    
+    fmt.Printf("Exported %d blocks to %s\n", n, opts.file)
+    return nil
+}
+
+// importChain validates and adds the blocks of a bootstrap file
+func importChain(bc *blockchain.Blockchain, opts *commandOptions) error {
+    f, err := os.Open(opts.file)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    
+    n, err := bc.ImportChain(f)
+    fmt.Printf("Imported %d blocks, chain height %d\n", n, bc.GetHeight())
+    return err
+}
+
+// exportBundle writes the offline verification bundle of a certification
+func exportBundle(bc *blockchain.Blockchain, opts *commandOptions) error {
+    b, err := bundle.Build(bc, opts.txID, opts.height)
+    if err != nil {
+        return err
+    }
+    
+    f, err := os.Create(opts.file)
+    if err != nil {
+        return err
+    }
+    
+    if err := b.Write(f); err != nil {
+        f.Close()
+        return err
+    }
+    if err := f.Close(); err != nil {
+        return err
+    }
+    
+    fmt.Printf("Exported certification %s at height %d with %d headers to %s\n",
+        opts.txID, b.Proof.Height, len(b.Headers), opts.file)
+    return nil
+}
+
+// verifyBundle checks a bundle file against a trusted checkpoint without opening
+// the database or the network. The consensus engine is the one the node configuration
+// selects, or proof of work with the network's difficulty limit without a configuration.
+func verifyBundle(name string, args []string) {
+    fs := flag.NewFlagSet(name, flag.ExitOnError)
+    file := fs.String("file", "", "Bundle file to verify")
+    configPath := fs.String("config", "", "Node configuration selecting the network and consensus engine")
+    chain := fs.String("chain", "", "Network the bundle must belong to without -config, defaults to the one it names")
+    checkpoint := fs.String("checkpoint", "", "Trusted hash of the block the bundle must end at")
+    fs.Parse(args)
+    
+    logger := utils.NewLogger(false)
+    
+    if *file == "" || *checkpoint == "" {
+        logger.Fatal("%s needs a -file and a -checkpoint", name)
+    }
+    
+    f, err := os.Open(*file)
+    if err != nil {
+        logger.Fatal("Failed to open bundle: %v", err)
+    }
+    b, err := bundle.Read(f)
+    f.Close()
+    if err != nil {
+        logger.Fatal("%v", err)
+    }
+    
+    var engine consensus.Engine
+    if *configPath != "" {
+        cfg, err := config.LoadConfig(*configPath)
+        if err != nil {
+            logger.Fatal("Failed to load configuration: %v", err)
+        }
+        if engine, err = blockchain.NewEngine(cfg); err != nil {
+            logger.Fatal("Failed to create consensus engine: %v", err)
+        }
+        *chain = cfg.Network.Chain
+    }
+    
+    if *chain == "" {
+        *chain = b.Network
+    }
+    params, err := chainparams.ForNetwork(*chain)
+    if err != nil {
+        logger.Fatal("%v", err)
+    }
+    
+    if engine == nil {
+        powLimit := consensus.CompactToBig(consensus.LeadingZerosToCompact(params.InitialDifficulty))
+        engine = consensus.NewProofOfWork(powLimit, 0, 0, 1)
+    }
+    
+    result, err := b.Verify(params, engine, *checkpoint)
+    if err != nil {
+        fmt.Printf("Bundle is INVALID: %v\n", err)
+        os.Exit(1)
+    }
+    
+    fmt.Printf("Bundle is valid\n")
+    fmt.Printf("  Certification: %s\n", result.TxID)
+    fmt.Printf("  Holder:        %s %s\n", result.Name, result.Surname)
+    fmt.Printf("  Public key:    %s\n", result.Fingerprint)
+    fmt.Printf("  Inquiry:       %s\n", result.InquiryID)
+    fmt.Printf("  Certified at:  %s\n", result.Datetime.Format(time.RFC3339))
+    fmt.Printf("  Block:         %s (height %d)\n", result.BlockHash, result.Height)
+    fmt.Printf("  Checkpoint:    %s (height %d, %d confirmations)\n",
+        result.Checkpoint, result.CheckpointHeight, result.Confirmations)
+    fmt.Printf("  Work:          %s\n", result.Work)
+}
\ No newline at end of file
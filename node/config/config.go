@@ -4,6 +4,8 @@ import (
     "fmt"
     "time"
     
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/mitchellh/mapstructure"
     "github.com/spf13/viper"
 )
 
@@ -14,11 +16,13 @@ type Config struct {
     Storage    StorageConfig    `yaml:"storage"`
     API        APIConfig        `yaml:"api"`
     Mining     MiningConfig     `yaml:"mining"`
+    Mempool    MempoolConfig    `yaml:"mempool"`
     Security   SecurityConfig   `yaml:"security"`
 }
 
 // NetworkConfig holds network-related configuration
 type NetworkConfig struct {
+    Chain          string        `yaml:"chain"`
     Port           int           `yaml:"port"`
     Host           string        `yaml:"host"`
     NetworkID      string        `yaml:"network_id"`
@@ -51,6 +55,9 @@ type APIConfig struct {
     PersonaAPIKey  string `yaml:"persona_api_key"`
     RateLimit      int    `yaml:"rate_limit"`
     Timeout        time.Duration `yaml:"timeout"`
+    
+    // Operator endpoints are served on 127.0.0.1 only, 0 disables them
+    AdminPort      int    `yaml:"admin_port"`
 }
 
 // MiningConfig holds mining-related configuration
@@ -61,6 +68,22 @@ type MiningConfig struct {
     DifficultyAdjust   int           `yaml:"difficulty_adjust"`
     TargetBlockTime    time.Duration `yaml:"target_block_time"`
     MaxTransPerBlock   int           `yaml:"max_trans_per_block"`
+    
+    // Block production policy
+    MinBatchSize       int           `yaml:"min_batch_size"`
+    MaxWait            time.Duration `yaml:"max_wait"`
+    MineEmptyBlocks    bool          `yaml:"mine_empty_blocks"`
+    
+    // Consensus engine: "pow" or "poa"
+    Engine             string        `yaml:"engine"`
+    Authorities        []string      `yaml:"authorities"`
+    AuthorityKey       string        `yaml:"authority_key"`
+    Period             time.Duration `yaml:"period"`
+}
+
+// MempoolConfig holds pending transaction pool configuration
+type MempoolConfig struct {
+    MaxSize int `yaml:"max_size"`
 }
 
 // SecurityConfig holds security-related configuration
@@ -84,8 +107,18 @@ func LoadConfig(path string) (*Config, error) {
         return nil, fmt.Errorf("failed to read config: %w", err)
     }
     
+    // Network parameters default to the preset of the selected chain
+    params, err := chainparams.ForNetwork(viper.GetString("network.chain"))
+    if err != nil {
+        return nil, fmt.Errorf("invalid configuration: %w", err)
+    }
+    setParamsDefaults(params)
+    
+    // Decode using the yaml tags so keys like difficulty_adjust map onto their fields
     var config Config
-    if err := viper.Unmarshal(&config); err != nil {
+    if err := viper.Unmarshal(&config, func(dc *mapstructure.DecoderConfig) {
+        dc.TagName = "yaml"
+    }); err != nil {
         return nil, fmt.Errorf("failed to unmarshal config: %w", err)
     }
     
@@ -100,19 +133,16 @@ func LoadConfig(path string) (*Config, error) {
 // setDefaults sets default configuration values
 func setDefaults() {
     // Network defaults
-    viper.SetDefault("network.port", 8333)
+    viper.SetDefault("network.chain", chainparams.MainNet.Name)
     viper.SetDefault("network.host", "0.0.0.0")
     viper.SetDefault("network.network_id", "CertificationBlockchain")
     viper.SetDefault("network.max_peers", 50)
-    viper.SetDefault("network.discovery_port", 45678)
-    viper.SetDefault("network.flag", "CERTIFICATION-BLOCKCHAIN-CLS")
     viper.SetDefault("network.timeout", "30s")
     
     // Blockchain defaults
     viper.SetDefault("blockchain.block_time", "10m")
     viper.SetDefault("blockchain.max_block_size", 1048576) // 1MB
     viper.SetDefault("blockchain.cert_expiry", "8760h") // 1 year
-    viper.SetDefault("blockchain.magic_value", 0xD9B4BEF9)
     
     // Storage defaults
     viper.SetDefault("storage.data_dir", "./data")
@@ -123,14 +153,22 @@ func setDefaults() {
     viper.SetDefault("api.persona_base_url", "https://api.withpersona.com/api/v1")
     viper.SetDefault("api.rate_limit", 100)
     viper.SetDefault("api.timeout", "30s")
+    viper.SetDefault("api.admin_port", 0)
     
     // Mining defaults
     viper.SetDefault("mining.enabled", true)
     viper.SetDefault("mining.threads", 4)
-    viper.SetDefault("mining.initial_difficulty", 16)
     viper.SetDefault("mining.difficulty_adjust", 2016)
     viper.SetDefault("mining.target_block_time", "10m")
     viper.SetDefault("mining.max_trans_per_block", 1000)
+    viper.SetDefault("mining.min_batch_size", 100)
+    viper.SetDefault("mining.max_wait", "30s")
+    viper.SetDefault("mining.mine_empty_blocks", false)
+    viper.SetDefault("mining.engine", "pow")
+    viper.SetDefault("mining.period", "15s")
+    
+    // Mempool defaults
+    viper.SetDefault("mempool.max_size", 33554432) // 32MB
     
     // Security defaults
     viper.SetDefault("security.require_signature", true)
@@ -139,16 +177,37 @@ func setDefaults() {
     viper.SetDefault("security.max_requests_per_min", 60)
 }
 
+// setParamsDefaults sets the defaults that come from the network preset
+func setParamsDefaults(params *chainparams.Params) {
+    viper.SetDefault("network.port", params.Port)
+    viper.SetDefault("network.discovery_port", params.DiscoveryPort)
+    viper.SetDefault("network.flag", params.Flag)
+    viper.SetDefault("blockchain.magic_value", params.MagicValue)
+    viper.SetDefault("mining.initial_difficulty", params.InitialDifficulty)
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
     if c.Network.Port < 1 || c.Network.Port > 65535 {
         return fmt.Errorf("invalid port: %d", c.Network.Port)
     }
     
+    if c.API.AdminPort < 0 || c.API.AdminPort > 65535 || c.API.AdminPort == c.Network.Port {
+        return fmt.Errorf("invalid admin port: %d", c.API.AdminPort)
+    }
+    
+    if c.Network.MaxPeers < 1 {
+        return fmt.Errorf("max peers must be at least 1")
+    }
+    
     if c.Network.NetworkID == "" {
         return fmt.Errorf("network ID cannot be empty")
     }
     
+    if err := c.validateParams(); err != nil {
+        return err
+    }
+    
     if c.API.PersonaAPIKey == "" {
         return fmt.Errorf("Persona API key is required")
     }
@@ -157,8 +216,83 @@ func (c *Config) Validate() error {
         return fmt.Errorf("mining threads must be at least 1")
     }
     
-    if c.Mining.InitialDifficulty < 1 {
-        return fmt.Errorf("initial difficulty must be at least 1")
+    if c.Mining.InitialDifficulty < 1 || c.Mining.InitialDifficulty > 255 {
+        return fmt.Errorf("initial difficulty must be between 1 and 255")
+    }
+    
+    if c.Mining.DifficultyAdjust < 1 {
+        return fmt.Errorf("difficulty adjustment interval must be at least 1")
+    }
+    
+    if c.Mining.TargetBlockTime <= 0 {
+        return fmt.Errorf("target block time must be positive")
+    }
+    
+    if c.Security.MaxInquiryAge <= 0 {
+        return fmt.Errorf("max inquiry age must be positive")
+    }
+    
+    if c.Mining.MaxTransPerBlock < 1 {
+        return fmt.Errorf("max transactions per block must be at least 1")
+    }
+    
+    if c.Mining.MinBatchSize < 1 {
+        return fmt.Errorf("minimum batch size must be at least 1")
+    }
+    
+    if c.Mining.MaxWait <= 0 {
+        return fmt.Errorf("maximum wait must be positive")
+    }
+    
+    if c.Blockchain.MaxBlockSize < 1 {
+        return fmt.Errorf("max block size must be positive")
+    }
+    
+    if c.Mempool.MaxSize < c.Blockchain.MaxBlockSize {
+        return fmt.Errorf("mempool size must be at least the max block size")
+    }
+    
+    if c.Mining.MineEmptyBlocks && c.Blockchain.BlockTime <= 0 {
+        return fmt.Errorf("block time must be positive to mine empty blocks")
+    }
+    
+    switch c.Mining.Engine {
+    case "pow":
+    case "poa":
+        if len(c.Mining.Authorities) == 0 {
+            return fmt.Errorf("proof of authority requires at least one authority")
+        }
+        if c.Mining.Period < time.Second {
+            return fmt.Errorf("block period must be at least one second")
+        }
+    default:
+        return fmt.Errorf("unknown consensus engine: %s", c.Mining.Engine)
+    }
+    
+    return nil
+}
+
+// validateParams checks that consensus settings agree with the network preset
+func (c *Config) validateParams() error {
+    params, err := chainparams.ForNetwork(c.Network.Chain)
+    if err != nil {
+        return err
+    }
+    
+    if c.Blockchain.MagicValue != params.MagicValue {
+        return fmt.Errorf("magic value %08x does not match the %s preset", c.Blockchain.MagicValue, params.Name)
+    }
+    
+    if c.Network.Flag != params.Flag {
+        return fmt.Errorf("network flag %q does not match the %s preset", c.Network.Flag, params.Name)
+    }
+    
+    if c.Mining.InitialDifficulty != params.InitialDifficulty {
+        return fmt.Errorf("initial difficulty %d does not match the %s preset", c.Mining.InitialDifficulty, params.Name)
+    }
+    
+    if c.Blockchain.GenesisHash != "" && c.Blockchain.GenesisHash != params.GenesisHash {
+        return fmt.Errorf("genesis hash %s does not match the %s preset", c.Blockchain.GenesisHash, params.Name)
     }
     
     return nil
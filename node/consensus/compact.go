@@ -0,0 +1,119 @@
+package consensus
+
+import (
+    "math/big"
+)
+
+// maxRetargetFactor limits how much the target can change in one retarget
+const maxRetargetFactor = 4
+
+var (
+    // bigOne is 1 represented as a big.Int
+    bigOne = big.NewInt(1)
+    
+    // oneLsh256 is 1 shifted left 256 bits
+    oneLsh256 = new(big.Int).Lsh(bigOne, 256)
+)
+
+// CompactToBig converts a compact target to a big integer. The compact format
+// is the Bitcoin nBits encoding: an 8-bit base-256 exponent followed by a sign
+// bit and a 23-bit mantissa.
+func CompactToBig(compact uint32) *big.Int {
+    mantissa := compact & 0x007fffff
+    isNegative := compact&0x00800000 != 0
+    exponent := uint(compact >> 24)
+    
+    var bn *big.Int
+    if exponent <= 3 {
+        mantissa >>= 8 * (3 - exponent)
+        bn = big.NewInt(int64(mantissa))
+    } else {
+        bn = big.NewInt(int64(mantissa))
+        bn.Lsh(bn, 8*(exponent-3))
+    }
+    
+    if isNegative {
+        bn = bn.Neg(bn)
+    }
+    
+    return bn
+}
+
+// BigToCompact converts a big integer to its compact target representation.
+// Only the three most significant bytes of the number are kept.
+func BigToCompact(n *big.Int) uint32 {
+    if n.Sign() == 0 {
+        return 0
+    }
+    
+    abs := new(big.Int).Abs(n)
+    exponent := uint(len(abs.Bytes()))
+    
+    var mantissa uint32
+    if exponent <= 3 {
+        mantissa = uint32(abs.Uint64())
+        mantissa <<= 8 * (3 - exponent)
+    } else {
+        mantissa = uint32(abs.Rsh(abs, 8*(exponent-3)).Uint64())
+    }
+    
+    // The sign bit is not part of the mantissa, move it to the exponent
+    if mantissa&0x00800000 != 0 {
+        mantissa >>= 8
+        exponent++
+    }
+    
+    compact := uint32(exponent<<24) | mantissa
+    if n.Sign() < 0 {
+        compact |= 0x00800000
+    }
+    
+    return compact
+}
+
+// LeadingZerosToCompact returns the compact target that requires a hash with
+// the given number of leading zero bits
+func LeadingZerosToCompact(zeros int) uint32 {
+    target := new(big.Int).Lsh(bigOne, uint(256-zeros))
+    return BigToCompact(target.Sub(target, bigOne))
+}
+
+// CalcWork returns the expected number of hashes needed to mine a block with the given compact target
+func CalcWork(bits uint32) *big.Int {
+    target := CompactToBig(bits)
+    if target.Sign() <= 0 {
+        return big.NewInt(0)
+    }
+    
+    // work = 2^256 / (target + 1)
+    denominator := new(big.Int).Add(target, bigOne)
+    return new(big.Int).Div(oneLsh256, denominator)
+}
+
+// CalculateDifficulty calculates the new compact target from the time taken by
+// the last retarget interval. The target never exceeds powLimit.
+func CalculateDifficulty(currentBits uint32, actualTime, targetTime int64, powLimit *big.Int) uint32 {
+    if targetTime <= 0 {
+        return currentBits
+    }
+    
+    // Limit the adjustment in either direction
+    minTime := targetTime / maxRetargetFactor
+    maxTime := targetTime * maxRetargetFactor
+    if actualTime < minTime {
+        actualTime = minTime
+    } else if actualTime > maxTime {
+        actualTime = maxTime
+    }
+    
+    // Blocks mined too quickly lower the target, blocks mined too slowly raise it
+    newTarget := CompactToBig(currentBits)
+    newTarget.Mul(newTarget, big.NewInt(actualTime))
+    newTarget.Div(newTarget, big.NewInt(targetTime))
+    
+    if newTarget.Cmp(powLimit) > 0 {
+        newTarget.Set(powLimit)
+    }
+    
+    return BigToCompact(newTarget)
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package consensus
+
+import (
+    "math/big"
+    "testing"
+)
+
+func TestCompactRoundTrip(t *testing.T) {
+    tests := []struct {
+        name    string
+        compact uint32
+        target  string
+        encoded uint32
+    }{
+        {"bitcoin genesis", 0x1d00ffff, "ffff0000000000000000000000000000000000000000000000000000", 0x1d00ffff},
+        {"regtest limit", 0x207fffff, "7fffff0000000000000000000000000000000000000000000000000000000000", 0x207fffff},
+        {"three byte exponent", 0x03123456, "123456", 0x03123456},
+        {"short exponent drops low bytes", 0x02123456, "1234", 0x02123400},
+        {"mantissa shifted out", 0x01003456, "0", 0},
+        {"negative", 0x04923456, "-12345600", 0x04923456},
+        {"sign bit moved to exponent", 0x05009234, "92340000", 0x05009234},
+        {"zero", 0, "0", 0},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            want, ok := new(big.Int).SetString(tt.target, 16)
+            if !ok {
+                t.Fatalf("bad target %q", tt.target)
+            }
+            
+            got := CompactToBig(tt.compact)
+            if got.Cmp(want) != 0 {
+                t.Fatalf("CompactToBig(%08x) = %x, want %x", tt.compact, got, want)
+            }
+            
+            if encoded := BigToCompact(got); encoded != tt.encoded {
+                t.Fatalf("BigToCompact(%x) = %08x, want %08x", got, encoded, tt.encoded)
+            }
+            
+            // The encoded form is canonical and survives another round trip
+            if again := BigToCompact(CompactToBig(tt.encoded)); again != tt.encoded {
+                t.Fatalf("round trip of %08x = %08x", tt.encoded, again)
+            }
+        })
+    }
+}
+
+func TestLeadingZerosToCompact(t *testing.T) {
+    tests := []struct {
+        zeros int
+        want  uint32
+    }{
+        {1, 0x207fffff},
+        {8, 0x2000ffff},
+        {16, 0x1f00ffff},
+        {20, 0x1e0fffff},
+        {32, 0x1d00ffff},
+    }
+    
+    for _, tt := range tests {
+        if got := LeadingZerosToCompact(tt.zeros); got != tt.want {
+            t.Errorf("LeadingZerosToCompact(%d) = %08x, want %08x", tt.zeros, got, tt.want)
+        }
+    }
+}
+
+func TestCalcWork(t *testing.T) {
+    tests := []struct {
+        bits uint32
+        want int64
+    }{
+        {0x1d00ffff, 4295032833},
+        {0x1f00ffff, 65537},
+        {0x207fffff, 2},
+        {0, 0},
+        {0x04923456, 0},
+    }
+    
+    for _, tt := range tests {
+        if got := CalcWork(tt.bits); got.Cmp(big.NewInt(tt.want)) != 0 {
+            t.Errorf("CalcWork(%08x) = %v, want %d", tt.bits, got, tt.want)
+        }
+    }
+}
+
+func TestCalculateDifficulty(t *testing.T) {
+    regtestLimit := CompactToBig(0x207fffff)
+    mainnetLimit := CompactToBig(0x1f00ffff)
+    
+    tests := []struct {
+        name       string
+        bits       uint32
+        actualTime int64
+        targetTime int64
+        powLimit   *big.Int
+        want       uint32
+    }{
+        {"on target", 0x1d00ffff, 1000, 1000, regtestLimit, 0x1d00ffff},
+        {"twice as slow", 0x1d00ffff, 2000, 1000, regtestLimit, 0x1d01fffe},
+        {"twice as fast", 0x1d00ffff, 500, 1000, regtestLimit, 0x1c7fff80},
+        {"at the fast clamp", 0x1d00ffff, 250, 1000, regtestLimit, 0x1c3fffc0},
+        {"faster than the clamp", 0x1d00ffff, 100, 1000, regtestLimit, 0x1c3fffc0},
+        {"no time at all", 0x1d00ffff, 0, 1000, regtestLimit, 0x1c3fffc0},
+        {"at the slow clamp", 0x1d00ffff, 4000, 1000, regtestLimit, 0x1d03fffc},
+        {"slower than the clamp", 0x1d00ffff, 10000, 1000, regtestLimit, 0x1d03fffc},
+        {"capped at the limit", 0x1f00ffff, 2000, 1000, mainnetLimit, 0x1f00ffff},
+        {"limit stays at the limit", 0x207fffff, 10000, 1000, regtestLimit, 0x207fffff},
+        {"no target time", 0x1d00ffff, 1000, 0, regtestLimit, 0x1d00ffff},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := CalculateDifficulty(tt.bits, tt.actualTime, tt.targetTime, tt.powLimit)
+            if got != tt.want {
+                t.Fatalf("got %08x, want %08x", got, tt.want)
+            }
+        })
+    }
+}
\ No newline at end of file
@@ -0,0 +1,34 @@
+package consensus
+
+import (
+    "context"
+    "errors"
+    "math/big"
+)
+
+// ErrInvalidSeal is returned when a header's seal does not satisfy the engine
+var ErrInvalidSeal = errors.New("invalid seal")
+
+// ChainReader gives an engine access to the branch of the chain it is extending
+type ChainReader interface {
+    // GetAncestor returns the header at the given height on the branch, or nil
+    GetAncestor(height uint64) *Header
+}
+
+// Engine is a consensus algorithm that seals and verifies block headers
+type Engine interface {
+    // Seal fills in the header's seal fields so that VerifySeal accepts it.
+    // It returns ctx.Err() when ctx is cancelled before a seal is found.
+    Seal(ctx context.Context, chain ChainReader, header *Header) error
+    
+    // VerifySeal checks the seal and difficulty of a header on top of chain.
+    // With a nil chain only the checks that need no ancestors are done.
+    VerifySeal(chain ChainReader, header *Header) error
+    
+    // CalcNextDifficulty returns the difficulty a header on top of parent must
+    // carry. Both chain and parent are nil for the genesis block.
+    CalcNextDifficulty(chain ChainReader, parent *Header) uint32
+    
+    // CalcWork returns the chain weight added by a block with the given difficulty
+    CalcWork(bits uint32) *big.Int
+}
\ No newline at end of file
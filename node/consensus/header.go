@@ -0,0 +1,71 @@
+package consensus
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/hex"
+    "time"
+)
+
+// Header contains the block metadata sealed by a consensus engine
+type Header struct {
+    Version        uint32    `json:"version"`
+    PrevBlockHash  string    `json:"prev_block_hash"`
+    MerkleRoot     string    `json:"merkle_root"`
+    StateRoot      string    `json:"state_root"`
+    Timestamp      time.Time `json:"timestamp"`
+    Bits           uint32    `json:"bits"`
+    Nonce          uint32    `json:"nonce"`
+    Height         uint64    `json:"height"`
+    
+    // Proof of authority seal and vote
+    Signer         string    `json:"signer,omitempty"`
+    Candidate      string    `json:"candidate,omitempty"`
+    Signature      string    `json:"signature,omitempty"`
+}
+
+// Hash calculates the hash of the header
+func (h *Header) Hash() string {
+    hash := h.hashBytes()
+    return hex.EncodeToString(hash[:])
+}
+
+// SealHash calculates the hash of the header without its signature, which is what signers sign
+func (h *Header) SealHash() string {
+    var buf bytes.Buffer
+    h.writeSealFields(&buf)
+    
+    hash := sha256.Sum256(buf.Bytes())
+    return hex.EncodeToString(hash[:])
+}
+
+// hashBytes calculates the raw SHA-256 hash of the header fields
+func (h *Header) hashBytes() [32]byte {
+    var buf bytes.Buffer
+    h.writeSealFields(&buf)
+    writeHashField(&buf, h.Signature)
+    
+    return sha256.Sum256(buf.Bytes())
+}
+
+// writeSealFields writes all header fields except the signature in order.
+// Strings are length prefixed so that bytes cannot move from one field to the next.
+func (h *Header) writeSealFields(buf *bytes.Buffer) {
+    binary.Write(buf, binary.BigEndian, h.Version)
+    writeHashField(buf, h.PrevBlockHash)
+    writeHashField(buf, h.MerkleRoot)
+    writeHashField(buf, h.StateRoot)
+    binary.Write(buf, binary.BigEndian, h.Timestamp.Unix())
+    binary.Write(buf, binary.BigEndian, h.Bits)
+    binary.Write(buf, binary.BigEndian, h.Nonce)
+    binary.Write(buf, binary.BigEndian, h.Height)
+    writeHashField(buf, h.Signer)
+    writeHashField(buf, h.Candidate)
+}
+
+// writeHashField writes a string preceded by its length
+func writeHashField(buf *bytes.Buffer, s string) {
+    binary.Write(buf, binary.BigEndian, uint32(len(s)))
+    buf.WriteString(s)
+}
\ No newline at end of file
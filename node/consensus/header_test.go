@@ -0,0 +1,115 @@
+package consensus
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+)
+
+// testHeader returns a header with every field set
+func testHeader() Header {
+    return Header{
+        Version:       1,
+        PrevBlockHash: strings.Repeat("ab", 32),
+        MerkleRoot:    strings.Repeat("cd", 32),
+        StateRoot:     strings.Repeat("ef", 32),
+        Timestamp:     time.Unix(1700000000, 0).UTC(),
+        Bits:          0x207fffff,
+        Nonce:         7,
+        Height:        12,
+    }
+}
+
+func TestHeaderHashCommitsToFields(t *testing.T) {
+    tests := []struct {
+        name   string
+        modify func(h *Header)
+    }{
+        {"height", func(h *Header) { h.Height++ }},
+        {"nonce", func(h *Header) { h.Nonce++ }},
+        {"timestamp", func(h *Header) { h.Timestamp = h.Timestamp.Add(time.Second) }},
+        {"bits", func(h *Header) { h.Bits-- }},
+        {"state root", func(h *Header) { h.StateRoot = strings.Repeat("00", 32) }},
+    }
+    
+    original := testHeader()
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            h := testHeader()
+            tt.modify(&h)
+            
+            if h.Hash() == original.Hash() {
+                t.Fatal("changed header kept its hash")
+            }
+        })
+    }
+}
+
+func TestHeaderHashSeparatesFields(t *testing.T) {
+    tests := []struct {
+        name        string
+        first, next func(h *Header)
+    }{
+        {"signer and candidate",
+            func(h *Header) { h.Signer, h.Candidate = "abc", "def" },
+            func(h *Header) { h.Signer, h.Candidate = "abcd", "ef" }},
+        {"candidate and signature",
+            func(h *Header) { h.Candidate, h.Signature = "abc", "def" },
+            func(h *Header) { h.Candidate, h.Signature = "abcdef", "" }},
+        {"signer and signature",
+            func(h *Header) { h.Signer = "abc" },
+            func(h *Header) { h.Signature = "abc" }},
+        {"merkle and state roots",
+            func(h *Header) { h.MerkleRoot, h.StateRoot = "ab", "cd" },
+            func(h *Header) { h.MerkleRoot, h.StateRoot = "abc", "d" }},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a, b := testHeader(), testHeader()
+            tt.first(&a)
+            tt.next(&b)
+            
+            if a.Hash() == b.Hash() {
+                t.Fatal("bytes moved between fields without changing the hash")
+            }
+        })
+    }
+}
+
+func TestProofOfWorkRejectsAuthorityFields(t *testing.T) {
+    limit := CompactToBig(0x207fffff)
+    pow := NewProofOfWork(limit, 0, time.Minute, 1)
+    
+    tests := []struct {
+        name   string
+        modify func(h *Header)
+    }{
+        {"signer", func(h *Header) { h.Signer = "signer" }},
+        {"candidate", func(h *Header) { h.Candidate = "candidate" }},
+        {"signature", func(h *Header) { h.Signature = "signature" }},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            h := testHeader()
+            tt.modify(&h)
+            if err := pow.Seal(context.Background(), nil, &h); err != nil {
+                t.Fatal(err)
+            }
+            
+            if err := pow.VerifySeal(nil, &h); err == nil {
+                t.Fatal("proof of work header with authority fields was accepted")
+            }
+        })
+    }
+    
+    h := testHeader()
+    if err := pow.Seal(context.Background(), nil, &h); err != nil {
+        t.Fatal(err)
+    }
+    if err := pow.VerifySeal(nil, &h); err != nil {
+        t.Fatal(err)
+    }
+}
\ No newline at end of file
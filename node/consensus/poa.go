@@ -0,0 +1,407 @@
+package consensus
+
+import (
+    "context"
+    "crypto/rsa"
+    "errors"
+    "fmt"
+    "math/big"
+    "math/rand"
+    "sync"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+)
+
+const (
+    // diffInTurn is the difficulty of a block sealed by the in-turn signer
+    diffInTurn = 2
+    
+    // diffNoTurn is the difficulty of a block sealed out of turn
+    diffNoTurn = 1
+    
+    // nonceAuthVote is the header nonce that votes to add the candidate
+    nonceAuthVote = 0xffffffff
+    
+    // nonceDropVote is the header nonce that votes to remove the candidate
+    nonceDropVote = 0
+    
+    // wiggleTime is the extra delay per signer before sealing out of turn
+    wiggleTime = 500 * time.Millisecond
+    
+    // snapshotCacheSize is the number of authority snapshots kept in memory
+    snapshotCacheSize = 1024
+)
+
+var (
+    // ErrUnauthorizedSigner is returned when a header is sealed by a key that is not a signer
+    ErrUnauthorizedSigner = errors.New("unauthorized signer")
+    
+    // ErrRecentlySigned is returned when a signer seals again before its turn comes back
+    ErrRecentlySigned = errors.New("signer signed recently")
+    
+    // ErrNoSigningKey is returned when sealing without an authority key
+    ErrNoSigningKey = errors.New("no authority key configured")
+)
+
+// proposal is a vote the local signer casts in the blocks it seals
+type proposal struct {
+    key       string
+    authorize bool
+}
+
+// ProofOfAuthority is a consensus engine in which a set of authority keys take
+// turns sealing blocks with signatures. Signers are added and removed by
+// majority votes carried in block headers.
+type ProofOfAuthority struct {
+    genesisSigners map[string]string
+    period         time.Duration
+    
+    signKey     *rsa.PrivateKey
+    signer      string
+    
+    mu        sync.Mutex
+    keys      map[string]string
+    snapshots map[string]*snapshot
+    order     []string
+    proposals map[string]proposal
+}
+
+// NewProofOfAuthority creates a proof of authority engine with the PEM encoded
+// genesis authority keys. signKey may be nil on nodes that only verify blocks.
+func NewProofOfAuthority(authorities []string, signKey *rsa.PrivateKey, period time.Duration) (*ProofOfAuthority, error) {
+    poa := &ProofOfAuthority{
+        genesisSigners: make(map[string]string),
+        period:         period,
+        keys:           make(map[string]string),
+        snapshots:      make(map[string]*snapshot),
+        proposals:      make(map[string]proposal),
+    }
+    
+    for _, key := range authorities {
+        fingerprint, err := crypto.GetPublicKeyFingerprint(key)
+        if err != nil {
+            return nil, fmt.Errorf("invalid authority key: %w", err)
+        }
+        poa.genesisSigners[fingerprint] = key
+        poa.keys[fingerprint] = key
+    }
+    
+    if len(poa.genesisSigners) == 0 {
+        return nil, fmt.Errorf("at least one authority is required")
+    }
+    
+    if signKey != nil {
+        pubKey, err := crypto.PublicKeyToPEM(&signKey.PublicKey)
+        if err != nil {
+            return nil, err
+        }
+        
+        fingerprint, err := crypto.GetPublicKeyFingerprint(pubKey)
+        if err != nil {
+            return nil, err
+        }
+        
+        poa.signKey = signKey
+        poa.signer = fingerprint
+    }
+    
+    return poa, nil
+}
+
+// Signer returns the fingerprint of the local authority key
+func (poa *ProofOfAuthority) Signer() string {
+    return poa.signer
+}
+
+// Propose makes the local signer vote to add or remove the authority with the given PEM public key
+func (poa *ProofOfAuthority) Propose(publicKey string, authorize bool) error {
+    fingerprint, err := candidateFingerprint(publicKey)
+    if err != nil {
+        return err
+    }
+    
+    poa.mu.Lock()
+    defer poa.mu.Unlock()
+    
+    poa.proposals[fingerprint] = proposal{key: publicKey, authorize: authorize}
+    return nil
+}
+
+// Discard drops a pending proposal for a candidate fingerprint
+func (poa *ProofOfAuthority) Discard(fingerprint string) {
+    poa.mu.Lock()
+    defer poa.mu.Unlock()
+    
+    delete(poa.proposals, fingerprint)
+}
+
+// SetHead makes the authorities after head the keys the standalone seal check
+// accepts. It must only be called with the main chain tip, so that snapshots of
+// side chains and unvalidated headers never change them.
+func (poa *ProofOfAuthority) SetHead(chain ChainReader, head *Header) error {
+    snap, err := poa.snapshot(chain, head)
+    if err != nil {
+        return err
+    }
+    
+    poa.mu.Lock()
+    defer poa.mu.Unlock()
+    
+    poa.keys = make(map[string]string, len(snap.signers))
+    for fingerprint, key := range snap.signers {
+        poa.keys[fingerprint] = key
+    }
+    
+    return nil
+}
+
+// Signers returns the fingerprints of the authorities allowed to seal the block after parent
+func (poa *ProofOfAuthority) Signers(chain ChainReader, parent *Header) ([]string, error) {
+    snap, err := poa.snapshot(chain, parent)
+    if err != nil {
+        return nil, err
+    }
+    
+    return snap.signerList(), nil
+}
+
+// Seal signs the header with the local authority key once it is allowed to.
+// Out of turn signers wait a random delay to give the in-turn signer priority.
+func (poa *ProofOfAuthority) Seal(ctx context.Context, chain ChainReader, header *Header) error {
+    if poa.signKey == nil {
+        return ErrNoSigningKey
+    }
+    
+    parent := chain.GetAncestor(header.Height - 1)
+    if parent == nil {
+        return fmt.Errorf("unknown parent of block %d", header.Height)
+    }
+    
+    snap, err := poa.snapshot(chain, parent)
+    if err != nil {
+        return err
+    }
+    
+    if _, ok := snap.signers[poa.signer]; !ok {
+        return ErrUnauthorizedSigner
+    }
+    if snap.signedRecently(header.Height, poa.signer) {
+        return ErrRecentlySigned
+    }
+    
+    header.Signer = poa.signer
+    header.Bits = poa.difficulty(snap, header.Height, poa.signer)
+    poa.prepareVote(snap, header)
+    
+    // Blocks must be at least one period apart
+    earliest := parent.Timestamp.Add(poa.period)
+    if header.Timestamp.Before(earliest) {
+        header.Timestamp = earliest
+    }
+    
+    delay := time.Until(header.Timestamp)
+    if header.Bits == diffNoTurn {
+        wiggle := time.Duration(len(snap.signers)/2+1) * wiggleTime
+        delay += time.Duration(rand.Int63n(int64(wiggle)))
+    }
+    
+    select {
+    case <-ctx.Done():
+        return ctx.Err()
+    case <-time.After(delay):
+    }
+    
+    signature, err := crypto.SignMessage(poa.signKey, header.SealHash())
+    if err != nil {
+        return err
+    }
+    header.Signature = signature
+    
+    return nil
+}
+
+// VerifySeal checks the header signature and, when chain is not nil, that the
+// signer was allowed to seal the block at that point of the chain
+func (poa *ProofOfAuthority) VerifySeal(chain ChainReader, header *Header) error {
+    if header.Candidate == "" {
+        if header.Nonce != nonceDropVote {
+            return fmt.Errorf("nonce must be zero without a candidate")
+        }
+    } else if header.Nonce != nonceAuthVote && header.Nonce != nonceDropVote {
+        return fmt.Errorf("invalid vote nonce %08x", header.Nonce)
+    }
+    
+    // Without the chain only the signature of a known authority can be checked
+    if chain == nil {
+        poa.mu.Lock()
+        key, ok := poa.keys[header.Signer]
+        poa.mu.Unlock()
+        
+        if !ok {
+            return ErrUnauthorizedSigner
+        }
+        return poa.verifySignature(key, header)
+    }
+    
+    parent := chain.GetAncestor(header.Height - 1)
+    if parent == nil {
+        return fmt.Errorf("unknown parent of block %d", header.Height)
+    }
+    
+    if header.Timestamp.Before(parent.Timestamp.Add(poa.period)) {
+        return fmt.Errorf("block sealed less than %s after its parent", poa.period)
+    }
+    
+    snap, err := poa.snapshot(chain, parent)
+    if err != nil {
+        return err
+    }
+    
+    key, ok := snap.signers[header.Signer]
+    if !ok {
+        return ErrUnauthorizedSigner
+    }
+    if snap.signedRecently(header.Height, header.Signer) {
+        return ErrRecentlySigned
+    }
+    
+    if expected := poa.difficulty(snap, header.Height, header.Signer); header.Bits != expected {
+        return fmt.Errorf("unexpected difficulty %d, expected %d", header.Bits, expected)
+    }
+    
+    return poa.verifySignature(key, header)
+}
+
+// verifySignature checks the header signature against a PEM encoded authority key
+func (poa *ProofOfAuthority) verifySignature(key string, header *Header) error {
+    if err := crypto.VerifyRSASignature(key, header.SealHash(), header.Signature); err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidSeal, err)
+    }
+    
+    return nil
+}
+
+// CalcNextDifficulty returns the difficulty of the local signer's block after parent
+func (poa *ProofOfAuthority) CalcNextDifficulty(chain ChainReader, parent *Header) uint32 {
+    if parent == nil {
+        return diffNoTurn
+    }
+    
+    snap, err := poa.snapshot(chain, parent)
+    if err != nil {
+        return diffNoTurn
+    }
+    
+    return poa.difficulty(snap, parent.Height+1, poa.signer)
+}
+
+// CalcWork returns the chain weight of a block, which is its difficulty
+func (poa *ProofOfAuthority) CalcWork(bits uint32) *big.Int {
+    return big.NewInt(int64(bits))
+}
+
+// difficulty returns the difficulty of a block sealed by signer at height
+func (poa *ProofOfAuthority) difficulty(snap *snapshot, height uint64, signer string) uint32 {
+    if snap.inTurn(height, signer) {
+        return diffInTurn
+    }
+    
+    return diffNoTurn
+}
+
+// prepareVote puts one of the local proposals that would change the signer set into the header
+func (poa *ProofOfAuthority) prepareVote(snap *snapshot, header *Header) {
+    poa.mu.Lock()
+    defer poa.mu.Unlock()
+    
+    header.Candidate = ""
+    header.Nonce = nonceDropVote
+    
+    for fingerprint, p := range poa.proposals {
+        if !snap.validVote(fingerprint, p.authorize) {
+            continue
+        }
+        
+        header.Candidate = p.key
+        if p.authorize {
+            header.Nonce = nonceAuthVote
+        }
+        return
+    }
+}
+
+// snapshot returns the authority state after parent, replaying headers from the
+// nearest cached snapshot or from genesis
+func (poa *ProofOfAuthority) snapshot(chain ChainReader, parent *Header) (*snapshot, error) {
+    var headers []*Header
+    var snap *snapshot
+    
+    for header := parent; snap == nil; {
+        hash := header.Hash()
+        
+        poa.mu.Lock()
+        snap = poa.snapshots[hash]
+        poa.mu.Unlock()
+        
+        if snap != nil {
+            break
+        }
+        
+        if header.Height == 0 {
+            snap = newSnapshot(hash, poa.genesisSigners)
+            break
+        }
+        
+        headers = append(headers, header)
+        
+        header = chain.GetAncestor(header.Height - 1)
+        if header == nil {
+            return nil, fmt.Errorf("missing ancestor of block %d", headers[len(headers)-1].Height)
+        }
+    }
+    
+    // Replay the headers from the oldest one
+    for i := len(headers) - 1; i >= 0; i-- {
+        next, err := snap.apply(headers[i])
+        if err != nil {
+            return nil, err
+        }
+        snap = next
+        poa.remember(snap)
+    }
+    
+    if len(headers) == 0 {
+        poa.remember(snap)
+    }
+    
+    return snap, nil
+}
+
+// remember caches a snapshot
+func (poa *ProofOfAuthority) remember(snap *snapshot) {
+    poa.mu.Lock()
+    defer poa.mu.Unlock()
+    
+    if _, ok := poa.snapshots[snap.hash]; ok {
+        return
+    }
+    
+    poa.snapshots[snap.hash] = snap
+    poa.order = append(poa.order, snap.hash)
+    
+    if len(poa.order) > snapshotCacheSize {
+        delete(poa.snapshots, poa.order[0])
+        poa.order = poa.order[1:]
+    }
+}
+
+// candidateFingerprint returns the fingerprint of a PEM encoded candidate key
+func candidateFingerprint(publicKey string) (string, error) {
+    fingerprint, err := crypto.GetPublicKeyFingerprint(publicKey)
+    if err != nil {
+        return "", fmt.Errorf("invalid candidate key: %w", err)
+    }
+    
+    return fingerprint, nil
+}
\ No newline at end of file
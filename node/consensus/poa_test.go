@@ -0,0 +1,277 @@
+package consensus
+
+import (
+    "crypto/rsa"
+    "errors"
+    "testing"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+)
+
+// headerChain is a ChainReader over consecutive headers starting at genesis
+type headerChain []*Header
+
+func (c headerChain) GetAncestor(height uint64) *Header {
+    if height >= uint64(len(c)) {
+        return nil
+    }
+    return c[height]
+}
+
+// testAuthority is an authority key pair
+type testAuthority struct {
+    key         *rsa.PrivateKey
+    publicKey   string
+    fingerprint string
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+    t.Helper()
+    
+    key, pub, err := crypto.GenerateRSAKeyPair(2048)
+    if err != nil {
+        t.Fatal(err)
+    }
+    publicKey, err := crypto.PublicKeyToPEM(pub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    fingerprint, err := crypto.GetPublicKeyFingerprint(publicKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    return &testAuthority{key: key, publicKey: publicKey, fingerprint: fingerprint}
+}
+
+// seal signs a header on top of parent by a, voting on candidate
+func (a *testAuthority) seal(t *testing.T, parent *Header, candidate string, authorize bool) *Header {
+    t.Helper()
+    
+    h := &Header{
+        Version:       1,
+        PrevBlockHash: parent.Hash(),
+        Timestamp:     parent.Timestamp.Add(time.Minute),
+        Height:        parent.Height + 1,
+        Bits:          diffNoTurn,
+        Signer:        a.fingerprint,
+        Candidate:     candidate,
+        Nonce:         nonceDropVote,
+    }
+    if candidate != "" && authorize {
+        h.Nonce = nonceAuthVote
+    }
+    a.sign(t, h)
+    
+    return h
+}
+
+// sealOn signs a header on top of chain by a with the difficulty poa expects
+func (a *testAuthority) sealOn(t *testing.T, poa *ProofOfAuthority, chain headerChain, candidate string, authorize bool) *Header {
+    t.Helper()
+    
+    parent := chain[len(chain)-1]
+    h := a.seal(t, parent, candidate, authorize)
+    
+    if snap, err := poa.snapshot(chain, parent); err == nil {
+        h.Bits = poa.difficulty(snap, h.Height, a.fingerprint)
+        a.sign(t, h)
+    }
+    
+    return h
+}
+
+// sign sets the header signature
+func (a *testAuthority) sign(t *testing.T, h *Header) {
+    t.Helper()
+    
+    signature, err := crypto.SignMessage(a.key, h.SealHash())
+    if err != nil {
+        t.Fatal(err)
+    }
+    h.Signature = signature
+}
+
+// testGenesis returns an authority genesis header
+func testGenesis() *Header {
+    return &Header{Version: 1, Timestamp: time.Unix(1700000000, 0).UTC()}
+}
+
+func TestRemovedAuthorityFailsStandaloneSeal(t *testing.T) {
+    a, b := newTestAuthority(t), newTestAuthority(t)
+    
+    poa, err := NewProofOfAuthority([]string{a.publicKey, b.publicKey}, nil, time.Second)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    genesis := testGenesis()
+    chain := headerChain{genesis}
+    
+    // Before the vote both authorities pass the standalone check
+    if err := poa.VerifySeal(nil, b.seal(t, genesis, "", false)); err != nil {
+        t.Fatalf("authority rejected before removal: %v", err)
+    }
+    
+    // Both authorities vote to drop b, which is a majority of two
+    chain = append(chain, a.seal(t, chain[0], b.publicKey, false))
+    chain = append(chain, b.seal(t, chain[1], b.publicKey, false))
+    
+    if err := poa.SetHead(chain, chain[2]); err != nil {
+        t.Fatal(err)
+    }
+    
+    if err := poa.VerifySeal(nil, b.seal(t, chain[2], "", false)); !errors.Is(err, ErrUnauthorizedSigner) {
+        t.Fatalf("removed authority: got %v, want %v", err, ErrUnauthorizedSigner)
+    }
+    if err := poa.VerifySeal(nil, a.seal(t, chain[2], "", false)); err != nil {
+        t.Fatalf("remaining authority rejected: %v", err)
+    }
+}
+
+func TestAuthorityVoting(t *testing.T) {
+    a, b, c := newTestAuthority(t), newTestAuthority(t), newTestAuthority(t)
+    
+    poa, err := NewProofOfAuthority([]string{a.publicKey, b.publicKey}, nil, time.Second)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    signersAt := func(chain headerChain) map[string]bool {
+        t.Helper()
+        
+        signers, err := poa.Signers(chain, chain[len(chain)-1])
+        if err != nil {
+            t.Fatal(err)
+        }
+        set := make(map[string]bool)
+        for _, fingerprint := range signers {
+            set[fingerprint] = true
+        }
+        return set
+    }
+    
+    // One vote of two is not a majority
+    chain := headerChain{testGenesis()}
+    chain = append(chain, a.sealOn(t, poa, chain, c.publicKey, true))
+    if signersAt(chain)[c.fingerprint] {
+        t.Fatal("candidate added by a single vote")
+    }
+    
+    // The second vote adds c, which may seal from then on
+    chain = append(chain, b.sealOn(t, poa, chain, c.publicKey, true))
+    if !signersAt(chain)[c.fingerprint] {
+        t.Fatal("candidate not added by a majority")
+    }
+    
+    header := c.sealOn(t, poa, chain, "", false)
+    if err := poa.VerifySeal(chain, header); err != nil {
+        t.Fatalf("added authority rejected: %v", err)
+    }
+    chain = append(chain, header)
+    
+    // Two of three vote c out again
+    chain = append(chain, a.sealOn(t, poa, chain, c.publicKey, false))
+    if !signersAt(chain)[c.fingerprint] {
+        t.Fatal("authority removed by a single vote")
+    }
+    chain = append(chain, b.sealOn(t, poa, chain, c.publicKey, false))
+    
+    signers := signersAt(chain)
+    if signers[c.fingerprint] || !signers[a.fingerprint] || !signers[b.fingerprint] {
+        t.Fatalf("signers after removal = %v, want only the genesis authorities", signers)
+    }
+    
+    if err := poa.VerifySeal(chain, c.seal(t, chain[len(chain)-1], "", false)); !errors.Is(err, ErrUnauthorizedSigner) {
+        t.Fatalf("removed authority: got %v, want %v", err, ErrUnauthorizedSigner)
+    }
+}
+
+func TestAuthoritySealOrder(t *testing.T) {
+    a, b, c, d := newTestAuthority(t), newTestAuthority(t), newTestAuthority(t), newTestAuthority(t)
+    
+    tests := []struct {
+        name    string
+        signers []*testAuthority
+        want    error
+    }{
+        {"distinct signers", []*testAuthority{a, b, c}, nil},
+        {"after another signer", []*testAuthority{a, b, a}, nil},
+        {"same signer twice in a row", []*testAuthority{a, a}, ErrRecentlySigned},
+        {"unknown signer", []*testAuthority{a, d}, ErrUnauthorizedSigner},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            poa, err := NewProofOfAuthority([]string{a.publicKey, b.publicKey, c.publicKey}, nil, time.Second)
+            if err != nil {
+                t.Fatal(err)
+            }
+            
+            chain := headerChain{testGenesis()}
+            last := len(tt.signers) - 1
+            for _, signer := range tt.signers[:last] {
+                header := signer.sealOn(t, poa, chain, "", false)
+                if err := poa.VerifySeal(chain, header); err != nil {
+                    t.Fatalf("block %d: %v", header.Height, err)
+                }
+                chain = append(chain, header)
+            }
+            
+            header := tt.signers[last].sealOn(t, poa, chain, "", false)
+            if err := poa.VerifySeal(chain, header); !errors.Is(err, tt.want) {
+                t.Fatalf("got %v, want %v", err, tt.want)
+            }
+        })
+    }
+}
+
+func TestSideChainSnapshotKeepsAuthorities(t *testing.T) {
+    a, b := newTestAuthority(t), newTestAuthority(t)
+    
+    poa, err := NewProofOfAuthority([]string{a.publicKey, b.publicKey}, nil, time.Second)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    genesis := testGenesis()
+    main := headerChain{genesis}
+    main = append(main, a.sealOn(t, poa, main, "", false))
+    if err := poa.SetHead(main, main[1]); err != nil {
+        t.Fatal(err)
+    }
+    
+    // A longer side chain removes b
+    side := headerChain{genesis}
+    side = append(side, b.sealOn(t, poa, side, b.publicKey, false))
+    side = append(side, a.sealOn(t, poa, side, b.publicKey, false))
+    
+    signers, err := poa.Signers(side, side[2])
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(signers) != 1 || signers[0] != a.fingerprint {
+        t.Fatalf("side chain signers = %v, want only %s", signers, a.fingerprint)
+    }
+    
+    // Its snapshot must not change the keys of the main chain
+    if err := poa.VerifySeal(nil, b.seal(t, main[1], "", false)); err != nil {
+        t.Fatalf("authority rejected after a side chain snapshot: %v", err)
+    }
+    
+    // Once the side chain becomes the main chain b is removed, and restored on a switch back
+    if err := poa.SetHead(side, side[2]); err != nil {
+        t.Fatal(err)
+    }
+    if err := poa.VerifySeal(nil, b.seal(t, side[2], "", false)); !errors.Is(err, ErrUnauthorizedSigner) {
+        t.Fatalf("removed authority: got %v, want %v", err, ErrUnauthorizedSigner)
+    }
+    
+    if err := poa.SetHead(main, main[1]); err != nil {
+        t.Fatal(err)
+    }
+    if err := poa.VerifySeal(nil, b.seal(t, main[1], "", false)); err != nil {
+        t.Fatalf("authority rejected after switching back: %v", err)
+    }
+}
\ No newline at end of file
@@ -1,200 +1,229 @@
 package consensus
 
 import (
-    "bytes"
-    "crypto/sha256"
-    "encoding/binary"
-    "encoding/hex"
+    "context"
     "fmt"
     "math"
     "math/big"
+    "sync"
+    "sync/atomic"
+    "time"
 )
 
-// ProofOfWork represents a proof of work consensus mechanism
+// sealCheckInterval is how many nonces a worker tries between cancellation checks
+const sealCheckInterval = 1 << 16
+
+// ProofOfWork is a proof of work consensus engine using compact targets
 type ProofOfWork struct {
-    targetBits uint32
-    target     *big.Int
+    powLimit        *big.Int
+    retargetBlocks  uint64
+    targetBlockTime time.Duration
+    threads         int
+    
+    // Hashrate accounting of the current or last seal
+    hashes          atomic.Uint64
+    sealStart       atomic.Int64
+    sealEnd         atomic.Int64
 }
 
-// NewProofOfWork creates a new proof of work instance
-func NewProofOfWork(bits uint32) *ProofOfWork {
-    target := big.NewInt(1)
-    target.Lsh(target, uint(256-bits))
+// NewProofOfWork creates a new proof of work engine that seals with the given
+// number of worker threads. The target is retargeted every retargetBlocks
+// blocks and can never be easier than powLimit.
+func NewProofOfWork(powLimit *big.Int, retargetBlocks uint64, targetBlockTime time.Duration, threads int) *ProofOfWork {
+    if threads < 1 {
+        threads = 1
+    }
     
     return &ProofOfWork{
-        targetBits: bits,
-        target:     target,
+        powLimit:        new(big.Int).Set(powLimit),
+        retargetBlocks:  retargetBlocks,
+        targetBlockTime: targetBlockTime,
+        threads:         threads,
     }
 }
 
-// Block interface for proof of work
-type Block interface {
-    Hash() string
-    GetHeader() BlockHeader
-    SetNonce(nonce uint32)
-}
-
-// BlockHeader interface
-type BlockHeader interface {
-    GetVersion() uint32
-    GetPrevBlockHash() string
-    GetMerkleRoot() string
-    GetTimestamp() int64
-    GetBits() uint32
-    GetNonce() uint32
-}
-
-// Mine mines a block
-func (pow *ProofOfWork) Mine(block interface{}) bool {
-    // Type assertion to access block methods
-    b, ok := block.(interface {
-        Hash() string
-        Header interface {
-            Version       uint32
-            PrevBlockHash string
-            MerkleRoot    string
-            Timestamp     interface{ Unix() int64 }
-            Bits          uint32
-            Nonce         uint32
-        }
-    })
+// Seal searches for a nonce that brings the header hash below its target. The
+// nonce space is split across the worker threads, and a worker that exhausts
+// its share rolls the header timestamp forward and starts over.
+func (pow *ProofOfWork) Seal(ctx context.Context, chain ChainReader, header *Header) error {
+    target, err := pow.target(header.Bits)
+    if err != nil {
+        return err
+    }
+    
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
     
-    if !ok {
-        return false
+    pow.hashes.Store(0)
+    pow.sealStart.Store(time.Now().UnixNano())
+    pow.sealEnd.Store(0)
+    defer func() {
+        pow.sealEnd.Store(time.Now().UnixNano())
+    }()
+    
+    var wg sync.WaitGroup
+    found := make(chan Header, pow.threads)
+    
+    share := (uint64(math.MaxUint32) + 1) / uint64(pow.threads)
+    for i := 0; i < pow.threads; i++ {
+        first := uint64(i) * share
+        last := first + share - 1
+        if i == pow.threads-1 {
+            last = math.MaxUint32
+        }
+        
+        wg.Add(1)
+        go func(work Header) {
+            defer wg.Done()
+            pow.mine(ctx, &work, target, uint32(first), uint32(last), found)
+        }(*header)
     }
     
+    select {
+    case <-ctx.Done():
+        wg.Wait()
+        return ctx.Err()
+    case sealed := <-found:
+        cancel()
+        wg.Wait()
+        
+        header.Nonce = sealed.Nonce
+        header.Timestamp = sealed.Timestamp
+        return nil
+    }
+}
+
+// mine tries the nonces from first to last on a worker's copy of the header
+// until it finds a seal or ctx is cancelled
+func (pow *ProofOfWork) mine(ctx context.Context, header *Header, target *big.Int, first, last uint32, found chan<- Header) {
     var hashInt big.Int
-    var hash [32]byte
-    nonce := uint32(0)
-    
-    fmt.Printf("Mining block with target bits: %d\n", pow.targetBits)
-    
-    for nonce < math.MaxUint32 {
-        // Update nonce in block header
-        if setter, ok := block.(interface{ SetNonce(uint32) }); ok {
-            setter.SetNonce(nonce)
-        } else {
-            // Direct field access for our block type
-            if header, ok := getBlockHeader(block); ok {
-                header.Nonce = nonce
+    var tried uint64
+    
+    for nonce := first; ; nonce++ {
+        if tried%sealCheckInterval == 0 {
+            if ctx.Err() != nil {
+                return
+            }
+            if tried > 0 {
+                pow.hashes.Add(sealCheckInterval)
             }
         }
+        tried++
         
-        // Calculate hash
-        hash = pow.calculateHash(block)
+        header.Nonce = nonce
+        hash := header.hashBytes()
         hashInt.SetBytes(hash[:])
         
-        // Check if hash meets target
-        if hashInt.Cmp(pow.target) == -1 {
-            fmt.Printf("Found valid nonce: %d\n", nonce)
-            return true
+        if hashInt.Cmp(target) <= 0 {
+            found <- *header
+            return
         }
         
-        nonce++
-        
-        // Show progress
-        if nonce%100000 == 0 {
-            fmt.Printf("Mining progress: %d hashes tried\n", nonce)
+        // The share is exhausted, roll the timestamp and start over
+        if nonce == last {
+            header.Timestamp = header.Timestamp.Add(time.Second)
+            nonce = first - 1
         }
     }
-    
-    return false
 }
 
-// Validate validates a block's proof of work
-func (pow *ProofOfWork) Validate(block interface{}) bool {
-    var hashInt big.Int
-    
-    hash := pow.calculateHash(block)
-    hashInt.SetBytes(hash[:])
+// Hashrate returns the hashes per second of the running seal, or of the last one
+func (pow *ProofOfWork) Hashrate() float64 {
+    start := pow.sealStart.Load()
+    if start == 0 {
+        return 0
+    }
     
-    return hashInt.Cmp(pow.target) == -1
-}
-
-// calculateHash calculates the hash of a block
-func (pow *ProofOfWork) calculateHash(block interface{}) [32]byte {
-    var buf bytes.Buffer
+    end := pow.sealEnd.Load()
+    if end == 0 {
+        end = time.Now().UnixNano()
+    }
     
-    // Get block header fields
-    if header, ok := getBlockHeader(block); ok {
-        binary.Write(&buf, binary.BigEndian, header.Version)
-        buf.WriteString(header.PrevBlockHash)
-        buf.WriteString(header.MerkleRoot)
-        binary.Write(&buf, binary.BigEndian, header.Timestamp.Unix())
-        binary.Write(&buf, binary.BigEndian, header.Bits)
-        binary.Write(&buf, binary.BigEndian, header.Nonce)
+    elapsed := time.Duration(end - start).Seconds()
+    if elapsed <= 0 {
+        return 0
     }
     
-    return sha256.Sum256(buf.Bytes())
+    return float64(pow.hashes.Load()) / elapsed
 }
 
-// getBlockHeader extracts header from block using reflection or type assertion
-func getBlockHeader(block interface{}) (struct {
-    Version       uint32
-    PrevBlockHash string
-    MerkleRoot    string
-    Timestamp     interface{ Unix() int64 }
-    Bits          uint32
-    Nonce         uint32
-}, bool) {
-    // Try to access Header field
-    if b, ok := block.(interface {
-        Header struct {
-            Version       uint32
-            PrevBlockHash string
-            MerkleRoot    string
-            Timestamp     interface{ Unix() int64 }
-            Bits          uint32
-            Nonce         uint32
+// VerifySeal checks that the header carries the expected target and that its
+// hash is below that target. Proof of authority fields must be empty.
+func (pow *ProofOfWork) VerifySeal(chain ChainReader, header *Header) error {
+    if header.Signer != "" || header.Candidate != "" || header.Signature != "" {
+        return fmt.Errorf("proof of work header carries proof of authority fields")
+    }
+    
+    if chain != nil {
+        parent := chain.GetAncestor(header.Height - 1)
+        if parent == nil {
+            return fmt.Errorf("unknown parent of block %d", header.Height)
         }
-    }); ok {
-        return b.Header, true
-    }
-    
-    return struct {
-        Version       uint32
-        PrevBlockHash string
-        MerkleRoot    string
-        Timestamp     interface{ Unix() int64 }
-        Bits          uint32
-        Nonce         uint32
-    }{}, false
-}
-
-// GetTarget returns the current target as a hex string
-func (pow *ProofOfWork) GetTarget() string {
-    return hex.EncodeToString(pow.target.Bytes())
+        
+        if expected := pow.CalcNextDifficulty(chain, parent); header.Bits != expected {
+            return fmt.Errorf("unexpected target bits %08x, expected %08x", header.Bits, expected)
+        }
+    }
+    
+    target, err := pow.target(header.Bits)
+    if err != nil {
+        return err
+    }
+    
+    hash := header.hashBytes()
+    if new(big.Int).SetBytes(hash[:]).Cmp(target) > 0 {
+        return ErrInvalidSeal
+    }
+    
+    return nil
 }
 
-// SetDifficulty updates the difficulty
-func (pow *ProofOfWork) SetDifficulty(bits uint32) {
-    pow.targetBits = bits
-    pow.target = big.NewInt(1)
-    pow.target.Lsh(pow.target, uint(256-bits))
+// CalcNextDifficulty returns the compact target for the block after parent.
+// The target is retargeted every retargetBlocks blocks by comparing the actual
+// time of the last interval with the target block time.
+func (pow *ProofOfWork) CalcNextDifficulty(chain ChainReader, parent *Header) uint32 {
+    if parent == nil {
+        return BigToCompact(pow.powLimit)
+    }
+    
+    // Only retarget at interval boundaries
+    height := parent.Height + 1
+    if pow.retargetBlocks == 0 || height%pow.retargetBlocks != 0 {
+        return parent.Bits
+    }
+    
+    // Measure the time taken by the blocks since the previous retarget
+    firstHeight := uint64(0)
+    if parent.Height > pow.retargetBlocks {
+        firstHeight = parent.Height - pow.retargetBlocks
+    }
+    first := chain.GetAncestor(firstHeight)
+    if first == nil {
+        return parent.Bits
+    }
+    
+    blocks := int64(parent.Height - first.Height)
+    if blocks == 0 {
+        return parent.Bits
+    }
+    
+    actualTime := parent.Timestamp.Unix() - first.Timestamp.Unix()
+    targetTime := int64(pow.targetBlockTime.Seconds()) * blocks
+    
+    return CalculateDifficulty(parent.Bits, actualTime, targetTime, pow.powLimit)
 }
 
-// GetDifficulty returns the current difficulty bits
-func (pow *ProofOfWork) GetDifficulty() uint32 {
-    return pow.targetBits
+// CalcWork returns the expected number of hashes needed to find a seal for the compact target
+func (pow *ProofOfWork) CalcWork(bits uint32) *big.Int {
+    return CalcWork(bits)
 }
 
-// CalculateDifficulty calculates new difficulty based on time taken
-func CalculateDifficulty(currentBits uint32, actualTime, targetTime int64) uint32 {
-    // Simple difficulty adjustment algorithm
-    // If blocks are being mined too quickly, increase difficulty
-    // If blocks are being mined too slowly, decrease difficulty
-    
-    if actualTime < targetTime/2 {
-        // Too fast, increase difficulty
-        if currentBits < 32 {
-            return currentBits + 1
-        }
-    } else if actualTime > targetTime*2 {
-        // Too slow, decrease difficulty
-        if currentBits > 1 {
-            return currentBits - 1
-        }
+// target decodes a compact target and checks it against the proof of work limit
+func (pow *ProofOfWork) target(bits uint32) (*big.Int, error) {
+    target := CompactToBig(bits)
+    if target.Sign() <= 0 || target.Cmp(pow.powLimit) > 0 {
+        return nil, fmt.Errorf("target %08x out of range", bits)
     }
     
-    return currentBits
+    return target, nil
 }
\ No newline at end of file
@@ -0,0 +1,54 @@
+package consensus
+
+import (
+    "testing"
+    "time"
+)
+
+// spacedChain returns headers from genesis to height with the given bits, spaced apart by interval
+func spacedChain(height uint64, bits uint32, interval time.Duration) headerChain {
+    start := time.Unix(1700000000, 0).UTC()
+    
+    chain := make(headerChain, 0, height+1)
+    for h := uint64(0); h <= height; h++ {
+        chain = append(chain, &Header{
+            Version:   1,
+            Timestamp: start.Add(time.Duration(h) * interval),
+            Height:    h,
+            Bits:      bits,
+        })
+    }
+    
+    return chain
+}
+
+func TestCalcNextDifficulty(t *testing.T) {
+    limit := CompactToBig(0x207fffff)
+    pow := NewProofOfWork(limit, 4, time.Minute, 1)
+    
+    tests := []struct {
+        name  string
+        chain headerChain
+        want  uint32
+    }{
+        {"between retargets", spacedChain(2, 0x1d00ffff, time.Second), 0x1d00ffff},
+        {"on target", spacedChain(3, 0x1d00ffff, time.Minute), 0x1d00ffff},
+        {"too fast is clamped", spacedChain(3, 0x1d00ffff, time.Second), 0x1c3fffc0},
+        {"too slow is clamped", spacedChain(3, 0x1d00ffff, time.Hour), 0x1d03fffc},
+        {"never easier than the limit", spacedChain(3, 0x207fffff, time.Hour), 0x207fffff},
+        {"second interval", spacedChain(7, 0x1d00ffff, 2*time.Minute), 0x1d01fffe},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            parent := tt.chain[len(tt.chain)-1]
+            if got := pow.CalcNextDifficulty(tt.chain, parent); got != tt.want {
+                t.Fatalf("got %08x, want %08x", got, tt.want)
+            }
+        })
+    }
+    
+    if got := pow.CalcNextDifficulty(nil, nil); got != 0x207fffff {
+        t.Fatalf("genesis difficulty = %08x, want the limit", got)
+    }
+}
\ No newline at end of file
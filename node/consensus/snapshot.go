@@ -0,0 +1,232 @@
+package consensus
+
+import (
+    "fmt"
+    "sort"
+)
+
+// vote is a single authorization vote cast by a signer in a block header
+type vote struct {
+    signer    string
+    candidate string
+    authorize bool
+}
+
+// tally counts the votes cast for a candidate
+type tally struct {
+    authorize bool
+    votes     int
+}
+
+// snapshot is the authority state after a given block: the current signers,
+// who signed recently and the open votes
+type snapshot struct {
+    hash    string
+    height  uint64
+    signers map[string]string
+    recents map[uint64]string
+    votes   []vote
+    tally   map[string]tally
+}
+
+// newSnapshot creates the authority state at the genesis block
+func newSnapshot(hash string, signers map[string]string) *snapshot {
+    snap := &snapshot{
+        hash:    hash,
+        signers: make(map[string]string, len(signers)),
+        recents: make(map[uint64]string),
+        tally:   make(map[string]tally),
+    }
+    
+    for fingerprint, key := range signers {
+        snap.signers[fingerprint] = key
+    }
+    
+    return snap
+}
+
+// copy creates a deep copy of the snapshot
+func (s *snapshot) copy() *snapshot {
+    cpy := &snapshot{
+        hash:    s.hash,
+        height:  s.height,
+        signers: make(map[string]string, len(s.signers)),
+        recents: make(map[uint64]string, len(s.recents)),
+        votes:   make([]vote, len(s.votes)),
+        tally:   make(map[string]tally, len(s.tally)),
+    }
+    
+    for fingerprint, key := range s.signers {
+        cpy.signers[fingerprint] = key
+    }
+    for height, signer := range s.recents {
+        cpy.recents[height] = signer
+    }
+    for candidate, t := range s.tally {
+        cpy.tally[candidate] = t
+    }
+    copy(cpy.votes, s.votes)
+    
+    return cpy
+}
+
+// signerList returns the signers sorted by fingerprint, which fixes the turn order
+func (s *snapshot) signerList() []string {
+    signers := make([]string, 0, len(s.signers))
+    for fingerprint := range s.signers {
+        signers = append(signers, fingerprint)
+    }
+    sort.Strings(signers)
+    
+    return signers
+}
+
+// inTurn checks if it is the signer's turn to seal the block at height
+func (s *snapshot) inTurn(height uint64, signer string) bool {
+    signers := s.signerList()
+    if len(signers) == 0 {
+        return false
+    }
+    
+    return signers[height%uint64(len(signers))] == signer
+}
+
+// recentLimit is the number of consecutive blocks in which a signer may only sign once
+func (s *snapshot) recentLimit() uint64 {
+    return uint64(len(s.signers)/2 + 1)
+}
+
+// signedRecently checks if the signer sealed one of the blocks a block at height may not follow
+func (s *snapshot) signedRecently(height uint64, signer string) bool {
+    limit := s.recentLimit()
+    for seen, recent := range s.recents {
+        if recent == signer && (height < limit || seen > height-limit) {
+            return true
+        }
+    }
+    
+    return false
+}
+
+// validVote checks if a vote would change the signer set
+func (s *snapshot) validVote(candidate string, authorize bool) bool {
+    _, isSigner := s.signers[candidate]
+    return isSigner != authorize
+}
+
+// cast records a vote and updates the candidate's tally
+func (s *snapshot) cast(v vote) bool {
+    if !s.validVote(v.candidate, v.authorize) {
+        return false
+    }
+    
+    t, ok := s.tally[v.candidate]
+    if ok && t.authorize != v.authorize {
+        return false
+    }
+    
+    t.authorize = v.authorize
+    t.votes++
+    s.tally[v.candidate] = t
+    s.votes = append(s.votes, v)
+    
+    return true
+}
+
+// uncast removes a previously cast vote from the tally
+func (s *snapshot) uncast(signer, candidate string) {
+    for i, v := range s.votes {
+        if v.signer != signer || v.candidate != candidate {
+            continue
+        }
+        
+        t := s.tally[candidate]
+        if t.votes > 1 {
+            t.votes--
+            s.tally[candidate] = t
+        } else {
+            delete(s.tally, candidate)
+        }
+        
+        s.votes = append(s.votes[:i], s.votes[i+1:]...)
+        return
+    }
+}
+
+// apply creates the snapshot after header. The header's seal must already be verified.
+func (s *snapshot) apply(header *Header) (*snapshot, error) {
+    if header.Height != s.height+1 {
+        return nil, fmt.Errorf("header %d does not follow snapshot %d", header.Height, s.height)
+    }
+    
+    snap := s.copy()
+    snap.hash = header.Hash()
+    snap.height = header.Height
+    
+    if _, ok := snap.signers[header.Signer]; !ok {
+        return nil, ErrUnauthorizedSigner
+    }
+    if snap.signedRecently(header.Height, header.Signer) {
+        return nil, ErrRecentlySigned
+    }
+    
+    // Forget signers that are allowed to sign again
+    limit := snap.recentLimit()
+    if header.Height >= limit {
+        delete(snap.recents, header.Height-limit)
+    }
+    snap.recents[header.Height] = header.Signer
+    
+    if header.Candidate == "" {
+        return snap, nil
+    }
+    
+    candidate, err := candidateFingerprint(header.Candidate)
+    if err != nil {
+        return nil, err
+    }
+    
+    // A new vote replaces the signer's previous vote for the same candidate
+    authorize := header.Nonce == nonceAuthVote
+    snap.uncast(header.Signer, candidate)
+    
+    if snap.cast(vote{signer: header.Signer, candidate: candidate, authorize: authorize}) {
+        if t := snap.tally[candidate]; t.votes > len(snap.signers)/2 {
+            snap.applyVote(candidate, header.Candidate, t.authorize)
+        }
+    }
+    
+    return snap, nil
+}
+
+// applyVote adds or removes a signer once a majority has voted for it
+func (s *snapshot) applyVote(candidate, key string, authorize bool) {
+    if authorize {
+        s.signers[candidate] = key
+    } else {
+        delete(s.signers, candidate)
+        
+        // The recent list shrinks with the signer set
+        limit := s.recentLimit()
+        if s.height >= limit {
+            delete(s.recents, s.height-limit)
+        }
+        
+        // Votes of the removed signer no longer count
+        for i := 0; i < len(s.votes); i++ {
+            if s.votes[i].signer == candidate {
+                s.uncast(s.votes[i].signer, s.votes[i].candidate)
+                i--
+            }
+        }
+    }
+    
+    // Start a fresh tally for the candidate
+    for i := 0; i < len(s.votes); i++ {
+        if s.votes[i].candidate == candidate {
+            s.votes = append(s.votes[:i], s.votes[i+1:]...)
+            i--
+        }
+    }
+    delete(s.tally, candidate)
+}
\ No newline at end of file
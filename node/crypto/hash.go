@@ -45,54 +45,6 @@ func CompareHashes(hash1, hash2 string) bool {
     return hash1 == hash2
 }
 
-// MerkleHash computes the hash of two hashes concatenated (for Merkle trees)
-func MerkleHash(left, right string) string {
-    leftBytes, _ := hex.DecodeString(left)
-    rightBytes, _ := hex.DecodeString(right)
-    
-    combined := append(leftBytes, rightBytes...)
-    hash := sha256.Sum256(combined)
-    
-    return hex.EncodeToString(hash[:])
-}
-
-// CalculateMerkleRoot calculates the Merkle root from a list of hashes
-func CalculateMerkleRoot(hashes []string) string {
-    if len(hashes) == 0 {
-        return ""
-    }
-    
-    if len(hashes) == 1 {
-        return hashes[0]
-    }
-    
-    // Create a copy to avoid modifying the original
-    currentLevel := make([]string, len(hashes))
-    copy(currentLevel, hashes)
-    
-    for len(currentLevel) > 1 {
-        nextLevel := make([]string, 0)
-        
-        for i := 0; i < len(currentLevel); i += 2 {
-            left := currentLevel[i]
-            
-            var right string
-            if i+1 < len(currentLevel) {
-                right = currentLevel[i+1]
-            } else {
-                // If odd number of hashes, duplicate the last one
-                right = left
-            }
-            
-            nextLevel = append(nextLevel, MerkleHash(left, right))
-        }
-        
-        currentLevel = nextLevel
-    }
-    
-    return currentLevel[0]
-}
-
 // DoubleHash performs SHA256(SHA256(data))
 func DoubleHash(data []byte) string {
     hash1 := sha256.Sum256(data)
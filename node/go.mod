@@ -6,6 +6,7 @@ require (
     github.com/btcsuite/btcd v0.24.0
     github.com/dgraph-io/badger/v4 v4.2.0
     github.com/gorilla/mux v1.8.1
+    github.com/mitchellh/mapstructure v1.5.0
     github.com/sirupsen/logrus v1.9.3
     github.com/spf13/viper v1.18.2
     golang.org/x/crypto v0.18.0
@@ -30,7 +31,6 @@ require (
     github.com/hashicorp/hcl v1.0.0 // indirect
     github.com/klauspost/compress v1.12.3 // indirect
     github.com/magiconair/properties v1.8.7 // indirect
-    github.com/mitchellh/mapstructure v1.5.0 // indirect
     github.com/pelletier/go-toml/v2 v2.1.0 // indirect
     github.com/pkg/errors v0.9.1 // indirect
     github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
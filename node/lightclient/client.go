@@ -0,0 +1,313 @@
+package lightclient
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+)
+
+const (
+    // maxHeadersPerRequest is the most headers full nodes serve per request
+    maxHeadersPerRequest = 2000
+    
+    // maxMessageSize is the largest binary message accepted from a node
+    maxMessageSize = 32 << 20
+    
+    // maxStoredHeaders is the number of recent headers kept in memory. It covers
+    // several difficulty retarget intervals so new headers can still be validated.
+    maxStoredHeaders = 10000
+)
+
+// Inclusion describes a certification proven to be part of the best header chain
+type Inclusion struct {
+    TxID          string
+    BlockHash     string
+    Height        uint64
+    Confirmations uint64
+}
+
+// Client keeps the headers of the best chain served by a set of full nodes and
+// verifies certifications against them with Merkle inclusion proofs. It never
+// downloads blocks.
+type Client struct {
+    params     *chainparams.Params
+    engine     consensus.Engine
+    peers      []string
+    httpClient *http.Client
+    
+    mu         sync.RWMutex
+    headers    *headerChain
+    maxHeaders int
+}
+
+// New creates a light client for a network. The engine must apply the same
+// consensus rules as the network's full nodes.
+func New(params *chainparams.Params, engine consensus.Engine, peers []string, timeout time.Duration) (*Client, error) {
+    genesis := blockchain.GenesisBlock(params)
+    if hash := genesis.Hash(); hash != params.GenesisHash {
+        return nil, fmt.Errorf("%s genesis block hashes to %s, expected %s", params.Name, hash, params.GenesisHash)
+    }
+    
+    c := &Client{
+        params:     params,
+        engine:     engine,
+        peers:      peers,
+        httpClient: &http.Client{Timeout: timeout},
+        headers:    newHeaderChain(engine, genesis.Header),
+        maxHeaders: maxStoredHeaders,
+    }
+    
+    return c, nil
+}
+
+// Height returns the height of the best known header
+func (c *Client) Height() uint64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    
+    return c.headers.tip()
+}
+
+// Header returns the best chain header at a height, unless it has been pruned
+func (c *Client) Header(height uint64) (*blockchain.BlockHeader, bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    
+    header := c.headers.GetAncestor(height)
+    if header == nil {
+        return nil, false
+    }
+    
+    cpy := *header
+    return &cpy, true
+}
+
+// Sync downloads and validates new headers from every peer and switches to the
+// chain with the most work. It fails only when no peer could be synced.
+func (c *Client) Sync(ctx context.Context) error {
+    var lastErr error
+    synced := 0
+    
+    for _, peer := range c.peers {
+        if err := c.syncPeer(ctx, peer); err != nil {
+            lastErr = fmt.Errorf("peer %s: %w", peer, err)
+            continue
+        }
+        synced++
+    }
+    
+    if synced == 0 && lastErr != nil {
+        return lastErr
+    }
+    
+    return nil
+}
+
+// syncPeer downloads the peer's headers after the last header both chains share
+// and adopts them when they carry more work than the local chain
+func (c *Client) syncPeer(ctx context.Context, peer string) error {
+    c.mu.RLock()
+    local := c.headers
+    c.mu.RUnlock()
+    
+    fork, err := c.forkPoint(ctx, peer, local)
+    if err != nil {
+        return err
+    }
+    
+    candidate := local.truncate(c.engine, fork)
+    
+    for {
+        batch, err := c.fetchHeaders(ctx, peer, candidate.tip()+1, maxHeadersPerRequest)
+        if err != nil {
+            return err
+        }
+        if len(batch) == 0 {
+            break
+        }
+        
+        if err := candidate.extend(c.engine, batch); err != nil {
+            return fmt.Errorf("invalid headers: %w", err)
+        }
+        candidate.prune(c.maxHeaders)
+        
+        if len(batch) < maxHeadersPerRequest {
+            break
+        }
+    }
+    
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    
+    if candidate.work.Cmp(c.headers.work) > 0 {
+        c.headers = candidate
+    }
+    
+    return nil
+}
+
+// forkPoint finds a height at which the peer's chain matches the local one,
+// stepping back exponentially from the local tip. A peer whose chain leaves the
+// local one below the stored headers cannot be followed.
+func (c *Client) forkPoint(ctx context.Context, peer string, local *headerChain) (uint64, error) {
+    height := local.tip()
+    
+    for step := uint64(1); ; step *= 2 {
+        headers, err := c.fetchHeaders(ctx, peer, height, 1)
+        if err != nil {
+            return 0, err
+        }
+        if len(headers) == 1 && headers[0].Hash() == local.GetAncestor(height).Hash() {
+            return height, nil
+        }
+        
+        if height == local.start {
+            return 0, fmt.Errorf("peer chain does not contain block %d of the local chain", height)
+        }
+        if height-local.start < step {
+            height = local.start
+        } else {
+            height -= step
+        }
+    }
+}
+
+// Verify checks that a certification is included in a block of the best header
+// chain. The transaction ID is recomputed from its contents, the certification
+// returned by a peer must be validly signed by its public key, and the Merkle
+// proof is checked against the locally validated header.
+func (c *Client) Verify(ctx context.Context, tx *blockchain.Transaction) (*Inclusion, error) {
+    txID := tx.Hash()
+    if tx.ID != "" && tx.ID != txID {
+        return nil, fmt.Errorf("transaction ID does not match its contents")
+    }
+    
+    var lastErr error
+    for _, peer := range c.peers {
+        resp, err := c.fetchProof(ctx, peer, txID)
+        if err != nil {
+            lastErr = fmt.Errorf("peer %s: %w", peer, err)
+            continue
+        }
+        
+        inclusion, err := c.checkProof(txID, resp)
+        if err != nil {
+            lastErr = fmt.Errorf("peer %s: %w", peer, err)
+            continue
+        }
+        
+        return inclusion, nil
+    }
+    
+    if lastErr == nil {
+        lastErr = fmt.Errorf("no peers configured")
+    }
+    return nil, lastErr
+}
+
+// checkProof verifies a proof response against the best header chain
+func (c *Client) checkProof(txID string, resp *blockchain.CertificationProof) (*Inclusion, error) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    
+    header := c.headers.GetAncestor(resp.Height)
+    if header == nil {
+        return nil, fmt.Errorf("block %d is not among the stored headers", resp.Height)
+    }
+    
+    if resp.Transaction == nil || resp.Transaction.ID != txID {
+        return nil, fmt.Errorf("proof is for a different transaction")
+    }
+    if err := resp.Verify(); err != nil {
+        return nil, err
+    }
+    
+    // A matching Merkle path only shows the peer mined it, not that the key holder signed it
+    if err := resp.Transaction.Validate(); err != nil {
+        return nil, fmt.Errorf("invalid certification: %w", err)
+    }
+    if err := resp.Transaction.VerifySignature(); err != nil {
+        return nil, fmt.Errorf("invalid certification signature: %w", err)
+    }
+    
+    if hash := header.Hash(); hash != resp.BlockHash {
+        return nil, fmt.Errorf("block %s is not the best chain block at height %d", resp.BlockHash, resp.Height)
+    }
+    
+    return &Inclusion{
+        TxID:          txID,
+        BlockHash:     resp.BlockHash,
+        Height:        resp.Height,
+        Confirmations: c.headers.tip() - resp.Height + 1,
+    }, nil
+}
+
+// fetchHeaders requests consecutive headers from a peer in the binary encoding
+func (c *Client) fetchHeaders(ctx context.Context, peer string, from uint64, count int) ([]blockchain.BlockHeader, error) {
+    url := fmt.Sprintf("http://%s/api/v1/headers?from=%d&count=%d", peer, from, count)
+    
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+    req.Header.Set("Accept", blockchain.BinaryContentType)
+    
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get headers: %w", err)
+    }
+    defer resp.Body.Close()
+    
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+    }
+    if contentType := resp.Header.Get("Content-Type"); contentType != blockchain.BinaryContentType {
+        return nil, fmt.Errorf("unexpected content type %q", contentType)
+    }
+    
+    payload, err := blockchain.ReadFrame(resp.Body, c.params.MagicValue, maxMessageSize)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read headers: %w", err)
+    }
+    
+    headers, err := blockchain.DeserializeHeaders(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode headers: %w", err)
+    }
+    
+    return headers, nil
+}
+
+// fetchProof requests the inclusion proof of a certification from a peer
+func (c *Client) fetchProof(ctx context.Context, peer, txID string) (*blockchain.CertificationProof, error) {
+    url := fmt.Sprintf("http://%s/api/v1/certifications/%s/proof", peer, txID)
+    
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+    
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get proof: %w", err)
+    }
+    defer resp.Body.Close()
+    
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+    }
+    
+    var proof blockchain.CertificationProof
+    if err := json.NewDecoder(resp.Body).Decode(&proof); err != nil {
+        return nil, fmt.Errorf("failed to decode proof: %w", err)
+    }
+    
+    return &proof, nil
+}
\ No newline at end of file
@@ -0,0 +1,357 @@
+package lightclient
+
+import (
+    "context"
+    "crypto/rsa"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+)
+
+// blockList is a ChainReader over blocks starting at genesis
+type blockList []*blockchain.Block
+
+func (l blockList) GetAncestor(height uint64) *consensus.Header {
+    if height >= uint64(len(l)) {
+        return nil
+    }
+    return &l[height].Header
+}
+
+// testAuthority is an authority key and the engine that seals with it
+type testAuthority struct {
+    key       *rsa.PrivateKey
+    publicKey string
+    engine    *consensus.ProofOfAuthority
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+    t.Helper()
+    
+    key, _, err := crypto.GenerateRSAKeyPair(1024)
+    if err != nil {
+        t.Fatal(err)
+    }
+    publicKey, err := crypto.PublicKeyToPEM(&key.PublicKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    engine, err := consensus.NewProofOfAuthority([]string{publicKey}, key, time.Second)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    return &testAuthority{key: key, publicKey: publicKey, engine: engine}
+}
+
+// mine seals count blocks on top of chain, putting txs into the first one. The
+// offset moves the timestamps so that forks get different hashes.
+func (a *testAuthority) mine(t *testing.T, chain blockList, count int, txs []*blockchain.Transaction, offset time.Duration) blockList {
+    t.Helper()
+    
+    chain = append(blockList(nil), chain...)
+    for i := 0; i < count; i++ {
+        parent := chain[len(chain)-1]
+        
+        block := blockchain.NewBlock(txs, parent.Hash(), parent.Header.Height+1)
+        block.Header.Timestamp = parent.Header.Timestamp.Add(time.Minute + offset)
+        if err := a.engine.Seal(context.Background(), chain, &block.Header); err != nil {
+            t.Fatal(err)
+        }
+        
+        chain = append(chain, block)
+        txs = nil
+    }
+    
+    return chain
+}
+
+// verifier returns an engine that only verifies the authority's seals
+func (a *testAuthority) verifier(t *testing.T) *consensus.ProofOfAuthority {
+    t.Helper()
+    
+    engine, err := consensus.NewProofOfAuthority([]string{a.publicKey}, nil, time.Second)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return engine
+}
+
+// testCertification returns a signed certification of a new key pair
+func testCertification(t *testing.T) *blockchain.Transaction {
+    t.Helper()
+    
+    key, _, err := crypto.GenerateRSAKeyPair(1024)
+    if err != nil {
+        t.Fatal(err)
+    }
+    publicKey, err := crypto.PublicKeyToPEM(&key.PublicKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    tx := blockchain.NewTransaction(publicKey, "Jane", "Doe", "inquiry-1", time.Now().Add(-time.Hour), "")
+    if tx.Signature, err = crypto.SignMessage(key, tx.GetSignableMessage()); err != nil {
+        t.Fatal(err)
+    }
+    return tx
+}
+
+// servePeer serves the headers and proofs of a chain like a full node and returns its address
+func servePeer(t *testing.T, chain blockList) string {
+    t.Helper()
+    
+    mux := http.NewServeMux()
+    mux.HandleFunc("/api/v1/headers", func(w http.ResponseWriter, r *http.Request) {
+        from, _ := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+        count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+        
+        var headers []blockchain.BlockHeader
+        for h := from; h < uint64(len(chain)) && len(headers) < count; h++ {
+            headers = append(headers, chain[h].Header)
+        }
+        
+        data, err := blockchain.SerializeHeaders(headers)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", blockchain.BinaryContentType)
+        blockchain.WriteFrame(w, chainparams.RegTest.MagicValue, data)
+    })
+    mux.HandleFunc("/api/v1/certifications/", func(w http.ResponseWriter, r *http.Request) {
+        txID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/certifications/"), "/proof")
+        for _, block := range chain {
+            if proof, err := block.CertificationProof(txID); err == nil {
+                json.NewEncoder(w).Encode(proof)
+                return
+            }
+        }
+        http.NotFound(w, r)
+    })
+    
+    server := httptest.NewServer(mux)
+    t.Cleanup(server.Close)
+    
+    return strings.TrimPrefix(server.URL, "http://")
+}
+
+// newTestClient creates a regtest light client for the peers
+func newTestClient(t *testing.T, engine consensus.Engine, peers ...string) *Client {
+    t.Helper()
+    
+    c, err := New(&chainparams.RegTest, engine, peers, 5*time.Second)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return c
+}
+
+func TestSyncValidatesHeaders(t *testing.T) {
+    authority, outsider := newTestAuthority(t), newTestAuthority(t)
+    genesis := blockList{blockchain.GenesisBlock(&chainparams.RegTest)}
+    valid := authority.mine(t, genesis, 4, nil, 0)
+    
+    tests := []struct {
+        name       string
+        chain      func() blockList
+        wantHeight uint64
+        wantErr    bool
+    }{
+        {"valid chain", func() blockList { return valid }, 4, false},
+        {"changed after sealing", func() blockList {
+            forged := authority.mine(t, valid, 1, nil, 0)
+            forged[5].Header.StateRoot = strings.Repeat("00", 32)
+            return forged
+        }, 0, true},
+        {"unknown signer", func() blockList {
+            unknown := authority.mine(t, valid, 1, nil, 0)
+            header := &unknown[5].Header
+            header.Signer = outsider.engine.Signer()
+            header.Signature, _ = crypto.SignMessage(outsider.key, header.SealHash())
+            return unknown
+        }, 0, true},
+        {"broken link", func() blockList {
+            broken := authority.mine(t, valid, 1, nil, 0)
+            broken[4] = authority.mine(t, valid[:4], 1, nil, time.Second)[4]
+            return broken
+        }, 0, true},
+        {"sealed before the median time past", func() blockList {
+            early := authority.mine(t, valid, 1, nil, 0)
+            header := &early[5].Header
+            header.Timestamp = valid[1].Header.Timestamp
+            header.Signature, _ = crypto.SignMessage(authority.key, header.SealHash())
+            return early
+        }, 0, true},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            c := newTestClient(t, authority.verifier(t), servePeer(t, tt.chain()))
+            
+            err := c.Sync(context.Background())
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("got error %v, want an error %v", err, tt.wantErr)
+            }
+            if height := c.Height(); height != tt.wantHeight {
+                t.Fatalf("height %d, want %d", height, tt.wantHeight)
+            }
+        })
+    }
+}
+
+func TestSyncSwitchesToMoreWork(t *testing.T) {
+    authority := newTestAuthority(t)
+    genesis := blockList{blockchain.GenesisBlock(&chainparams.RegTest)}
+    
+    short := authority.mine(t, genesis, 3, nil, 0)
+    long := authority.mine(t, short[:2], 4, nil, time.Second)
+    
+    c := newTestClient(t, authority.verifier(t), servePeer(t, short))
+    if err := c.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    
+    // The longer fork replaces the local chain from the fork point
+    c.peers = []string{servePeer(t, long)}
+    if err := c.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    if height := c.Height(); height != 5 {
+        t.Fatalf("height %d after the fork, want 5", height)
+    }
+    if header, _ := c.Header(2); header.Hash() != long[2].Hash() {
+        t.Fatal("header 2 is not the one of the fork")
+    }
+    
+    // A chain with less work is ignored
+    c.peers = []string{servePeer(t, short)}
+    if err := c.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    if header, _ := c.Header(5); header.Hash() != long[5].Hash() {
+        t.Fatal("switched to a chain with less work")
+    }
+}
+
+func TestVerifyProof(t *testing.T) {
+    authority := newTestAuthority(t)
+    genesis := blockList{blockchain.GenesisBlock(&chainparams.RegTest)}
+    
+    tx := testCertification(t)
+    chain := authority.mine(t, genesis, 1, nil, 0)
+    chain = authority.mine(t, chain, 3, []*blockchain.Transaction{tx, testCertification(t)}, 0)
+    
+    c := newTestClient(t, authority.verifier(t), servePeer(t, chain))
+    if err := c.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    
+    inclusion, err := c.Verify(context.Background(), tx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if inclusion.Height != 2 || inclusion.BlockHash != chain[2].Hash() || inclusion.Confirmations != 3 {
+        t.Fatalf("inclusion %+v, want block 2 with 3 confirmations", inclusion)
+    }
+    
+    // A peer proving the certification in a block of another chain is not trusted
+    forged := authority.mine(t, chain[:2], 1, []*blockchain.Transaction{tx}, time.Second)
+    proof, err := forged[2].CertificationProof(tx.ID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := c.checkProof(tx.ID, proof); err == nil {
+        t.Fatal("proof against a header outside the synced chain was accepted")
+    }
+    
+    tests := []struct {
+        name  string
+        forge func(p *blockchain.CertificationProof)
+    }{
+        {"unsynced height", func(p *blockchain.CertificationProof) { p.Height = 9 }},
+        {"changed certification", func(p *blockchain.CertificationProof) { p.Transaction.Name = "John" }},
+        {"other transaction", func(p *blockchain.CertificationProof) { p.Transaction = testCertification(t) }},
+        {"changed Merkle path", func(p *blockchain.CertificationProof) {
+            p.Proof.Siblings[0] = strings.Repeat("00", 32)
+        }},
+        {"forged header", func(p *blockchain.CertificationProof) {
+            p.Header.Nonce++
+            p.BlockHash = p.Header.Hash()
+        }},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            proof, err := chain[2].CertificationProof(tx.ID)
+            if err != nil {
+                t.Fatal(err)
+            }
+            cpy := *proof.Transaction
+            proof.Transaction = &cpy
+            tt.forge(proof)
+            
+            if _, err := c.checkProof(tx.ID, proof); err == nil {
+                t.Fatal("forged proof was accepted")
+            }
+        })
+    }
+}
+
+func TestPruneStoredHeaders(t *testing.T) {
+    authority := newTestAuthority(t)
+    genesis := blockList{blockchain.GenesisBlock(&chainparams.RegTest)}
+    chain := authority.mine(t, genesis, 10, nil, 0)
+    
+    full := newTestClient(t, authority.verifier(t), servePeer(t, chain))
+    if err := full.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    
+    c := newTestClient(t, authority.verifier(t), servePeer(t, chain[:6]))
+    c.maxHeaders = 3
+    if err := c.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    
+    // Syncing on from the stored headers keeps extending the chain
+    c.peers = []string{servePeer(t, chain)}
+    if err := c.Sync(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    
+    if n := len(c.headers.headers); n != 3 {
+        t.Fatalf("%d headers stored, want 3", n)
+    }
+    if height := c.Height(); height != 10 {
+        t.Fatalf("height %d, want 10", height)
+    }
+    if _, ok := c.Header(7); ok {
+        t.Fatal("pruned header is still returned")
+    }
+    if header, ok := c.Header(8); !ok || header.Hash() != chain[8].Hash() {
+        t.Fatal("recent header is missing")
+    }
+    if c.headers.work.Cmp(full.headers.work) != 0 {
+        t.Fatalf("work %s after pruning, want %s", c.headers.work, full.headers.work)
+    }
+    
+    // A fork below the stored headers cannot be followed
+    fork := authority.mine(t, chain[:3], 12, nil, time.Second)
+    c.peers = []string{servePeer(t, fork)}
+    if err := c.Sync(context.Background()); err == nil {
+        t.Fatal("fork below the stored headers was followed")
+    }
+    if header, _ := c.Header(10); header.Hash() != chain[10].Hash() {
+        t.Fatal("chain changed after a failed sync")
+    }
+}
\ No newline at end of file
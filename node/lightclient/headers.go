@@ -0,0 +1,99 @@
+package lightclient
+
+import (
+    "fmt"
+    "math/big"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/consensus"
+)
+
+// headerChain is the newest part of a header chain. The first header is at height
+// start and every later header follows the one before it. Older headers are pruned,
+// but their work still counts towards the chain's total.
+type headerChain struct {
+    start   uint64
+    headers []blockchain.BlockHeader
+    work    *big.Int
+}
+
+// newHeaderChain creates a chain holding only the genesis header
+func newHeaderChain(engine consensus.Engine, genesis blockchain.BlockHeader) *headerChain {
+    return &headerChain{
+        headers: []blockchain.BlockHeader{genesis},
+        work:    new(big.Int).Set(engine.CalcWork(genesis.Bits)),
+    }
+}
+
+// tip returns the height of the last header
+func (hc *headerChain) tip() uint64 {
+    return hc.start + uint64(len(hc.headers)) - 1
+}
+
+// GetAncestor returns the header at a height, implementing consensus.ChainReader.
+// Pruned heights return nil.
+func (hc *headerChain) GetAncestor(height uint64) *consensus.Header {
+    if height < hc.start || height > hc.tip() {
+        return nil
+    }
+    
+    return &hc.headers[height-hc.start]
+}
+
+// truncate returns a copy of the chain ending at height, with room for more headers
+func (hc *headerChain) truncate(engine consensus.Engine, height uint64) *headerChain {
+    n := height - hc.start + 1
+    
+    cpy := &headerChain{
+        start:   hc.start,
+        headers: make([]blockchain.BlockHeader, n, n+maxHeadersPerRequest),
+        work:    new(big.Int).Set(hc.work),
+    }
+    copy(cpy.headers, hc.headers[:n])
+    
+    for i := n; i < uint64(len(hc.headers)); i++ {
+        cpy.work.Sub(cpy.work, engine.CalcWork(hc.headers[i].Bits))
+    }
+    
+    return cpy
+}
+
+// extend validates headers one by one on top of the chain and appends them
+func (hc *headerChain) extend(engine consensus.Engine, headers []blockchain.BlockHeader) error {
+    for i := range headers {
+        header := headers[i]
+        parent := &hc.headers[len(hc.headers)-1]
+        
+        if header.Height != parent.Height+1 {
+            return fmt.Errorf("header %d does not follow height %d", header.Height, parent.Height)
+        }
+        if header.PrevBlockHash != parent.Hash() {
+            return fmt.Errorf("header %d does not link to its parent", header.Height)
+        }
+        
+        if err := blockchain.CheckHeaderTimestamp(hc, &header, time.Now()); err != nil {
+            return fmt.Errorf("header %d: %w", header.Height, err)
+        }
+        if err := engine.VerifySeal(hc, &header); err != nil {
+            return fmt.Errorf("header %d has an invalid seal: %w", header.Height, err)
+        }
+        
+        hc.headers = append(hc.headers, header)
+        hc.work.Add(hc.work, engine.CalcWork(header.Bits))
+    }
+    
+    return nil
+}
+
+// prune drops the oldest headers so that at most keep headers remain
+func (hc *headerChain) prune(keep int) {
+    drop := len(hc.headers) - keep
+    if keep < 1 || drop <= 0 {
+        return
+    }
+    
+    // Copy the rest so the dropped headers can be freed
+    hc.headers = append([]blockchain.BlockHeader(nil), hc.headers[drop:]...)
+    hc.start += uint64(drop)
+}
\ No newline at end of file
@@ -3,8 +3,6 @@ package main
 import (
     "context"
     "flag"
-    "fmt"
-    "log"
     "os"
     "os/signal"
     "syscall"
@@ -17,6 +15,11 @@ import (
 )
 
 func main() {
+    // Offline commands such as export-chain and import-chain
+    if runCommand(os.Args[1:]) {
+        return
+    }
+    
     // Parse command line flags
     var (
         configPath = flag.String("config", "config/config.yaml", "Path to configuration file")
@@ -52,7 +55,7 @@ func main() {
     defer db.Close()
 
     // Initialize blockchain
-    bc, err := blockchain.NewBlockchain(db, logger)
+    bc, err := blockchain.NewBlockchain(cfg, db, logger)
     if err != nil {
         logger.Fatal("Failed to initialize blockchain: %v", err)
     }
@@ -77,6 +80,10 @@ func main() {
     discovery := network.NewDiscovery(server, logger)
     go discovery.Start(ctx)
 
+    // Start chain synchronization
+    syncManager := network.NewSyncManager(server, logger)
+    go syncManager.Start(ctx)
+
     // Setup graceful shutdown
     sigChan := make(chan os.Signal, 1)
     signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
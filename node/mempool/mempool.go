@@ -0,0 +1,321 @@
+package mempool
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/storage"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+var (
+    // ErrKnown is returned for a transaction that is already pending
+    ErrKnown = errors.New("transaction already in pool")
+    
+    // ErrDuplicateInquiry is returned when another pending transaction uses the same inquiry ID
+    ErrDuplicateInquiry = errors.New("inquiry ID already exists")
+    
+    // ErrDuplicatePublicKey is returned when another pending transaction certifies the same public key
+    ErrDuplicatePublicKey = errors.New("public key already has a pending certification")
+    
+    // ErrExpired is returned for a transaction older than the maximum inquiry age
+    ErrExpired = errors.New("transaction is older than the maximum inquiry age")
+    
+    // ErrFull is returned when a transaction does not fit in the pool size limit
+    ErrFull = errors.New("mempool is full")
+)
+
+// Entry is a pending transaction in the binary encoding with the fields the pool
+// indexes it by
+type Entry struct {
+    ID        string
+    InquiryID string
+    PublicKey string
+    Datetime  time.Time
+    Arrived   time.Time
+    Data      []byte
+}
+
+// DecodeFunc rebuilds an entry from an encoded transaction
+type DecodeFunc func(data []byte) (*Entry, error)
+
+// Stats describes the contents of the pool
+type Stats struct {
+    Count   int       `json:"transactions"`
+    Size    int       `json:"size"`
+    MaxSize int       `json:"max_size"`
+    Oldest  time.Time `json:"oldest_arrival"`
+}
+
+// Pool holds the transactions waiting to be mined in arrival order. Entries are
+// indexed by ID, inquiry ID and public key, persisted so they survive restarts,
+// and dropped once their datetime is older than maxAge. The total encoded size
+// is capped at maxSize; a full pool refuses new transactions instead of
+// silently evicting accepted ones.
+type Pool struct {
+    mu        sync.Mutex
+    db        *storage.Database
+    decode    DecodeFunc
+    logger    *utils.Logger
+    maxSize   int
+    maxAge    time.Duration
+    
+    queue     []*Entry
+    byID      map[string]*Entry
+    byInquiry map[string]*Entry
+    byKey     map[string]*Entry
+    size      int
+}
+
+// New creates a pool and restores the pending transactions persisted in the
+// database with decode. Stored entries that are expired, no longer fit or fail
+// to decode are discarded.
+func New(db *storage.Database, maxSize int, maxAge time.Duration, decode DecodeFunc, logger *utils.Logger) (*Pool, error) {
+    p := &Pool{
+        db:        db,
+        decode:    decode,
+        logger:    logger,
+        maxSize:   maxSize,
+        maxAge:    maxAge,
+        queue:     make([]*Entry, 0),
+        byID:      make(map[string]*Entry),
+        byInquiry: make(map[string]*Entry),
+        byKey:     make(map[string]*Entry),
+    }
+    
+    stored, err := db.GetPoolEntries()
+    if err != nil {
+        return nil, fmt.Errorf("failed to load mempool: %w", err)
+    }
+    
+    var discard []string
+    entries := make([]*Entry, 0, len(stored))
+    for id, data := range stored {
+        e, err := p.decodeEntry(data)
+        if err == nil && e.ID != id {
+            err = fmt.Errorf("decoded transaction ID %s does not match", e.ID)
+        }
+        if err != nil {
+            logger.Warn("Discarding stored pool entry %s: %v", id, err)
+            discard = append(discard, id)
+            continue
+        }
+        entries = append(entries, e)
+    }
+    
+    sort.Slice(entries, func(i, j int) bool {
+        return entries[i].Arrived.Before(entries[j].Arrived)
+    })
+    
+    now := time.Now()
+    for _, e := range entries {
+        if err := p.check(e, now); err != nil {
+            discard = append(discard, e.ID)
+            continue
+        }
+        p.insert(e)
+    }
+    
+    if len(discard) > 0 {
+        if err := db.DeletePoolEntries(discard); err != nil {
+            return nil, fmt.Errorf("failed to discard stale mempool entries: %w", err)
+        }
+    }
+    
+    return p, nil
+}
+
+// Add persists a transaction and adds it to the pool
+func (p *Pool) Add(e *Entry) error {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    
+    now := time.Now()
+    if err := p.expire(now); err != nil {
+        return err
+    }
+    
+    if err := p.check(e, now); err != nil {
+        return err
+    }
+    
+    if e.Arrived.IsZero() {
+        e.Arrived = now
+    }
+    
+    if err := p.db.SavePoolEntry(e.ID, encodeEntry(e)); err != nil {
+        return fmt.Errorf("failed to persist transaction: %w", err)
+    }
+    
+    p.insert(e)
+    return nil
+}
+
+// encodeEntry stores the arrival time in Unix nanoseconds followed by the encoded transaction
+func encodeEntry(e *Entry) []byte {
+    data := binary.BigEndian.AppendUint64(nil, uint64(e.Arrived.UnixNano()))
+    return append(data, e.Data...)
+}
+
+// decodeEntry rebuilds a stored entry
+func (p *Pool) decodeEntry(data []byte) (*Entry, error) {
+    if len(data) < 8 {
+        return nil, fmt.Errorf("pool entry of %d bytes is too short", len(data))
+    }
+    
+    e, err := p.decode(data[8:])
+    if err != nil {
+        return nil, err
+    }
+    e.Arrived = time.Unix(0, int64(binary.BigEndian.Uint64(data[:8])))
+    
+    return e, nil
+}
+
+// check reports why an entry cannot be added to the pool at now, if it cannot
+func (p *Pool) check(e *Entry, now time.Time) error {
+    if _, ok := p.byID[e.ID]; ok {
+        return ErrKnown
+    }
+    if _, ok := p.byInquiry[e.InquiryID]; ok {
+        return ErrDuplicateInquiry
+    }
+    if _, ok := p.byKey[e.PublicKey]; ok {
+        return ErrDuplicatePublicKey
+    }
+    
+    if now.Sub(e.Datetime) > p.maxAge {
+        return ErrExpired
+    }
+    
+    if p.size+len(e.Data) > p.maxSize {
+        return ErrFull
+    }
+    
+    return nil
+}
+
+// insert indexes an entry, the caller must hold p.mu
+func (p *Pool) insert(e *Entry) {
+    p.queue = append(p.queue, e)
+    p.byID[e.ID] = e
+    p.byInquiry[e.InquiryID] = e
+    p.byKey[e.PublicKey] = e
+    p.size += len(e.Data)
+}
+
+// Remove drops the pending transactions that share the ID, inquiry ID or public
+// key of one of the given entries, typically the transactions of a newly
+// connected block. Names are not unique, so entries are never matched by them.
+func (p *Pool) Remove(mined []*Entry) error {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    
+    drop := make(map[string]bool)
+    for _, m := range mined {
+        if e, ok := p.byID[m.ID]; ok {
+            drop[e.ID] = true
+        }
+        if e, ok := p.byInquiry[m.InquiryID]; ok {
+            drop[e.ID] = true
+        }
+        if e, ok := p.byKey[m.PublicKey]; ok {
+            drop[e.ID] = true
+        }
+    }
+    
+    return p.remove(drop)
+}
+
+// expire drops entries older than the maximum inquiry age, the caller must hold p.mu
+func (p *Pool) expire(now time.Time) error {
+    drop := make(map[string]bool)
+    for _, e := range p.queue {
+        if now.Sub(e.Datetime) > p.maxAge {
+            drop[e.ID] = true
+        }
+    }
+    
+    return p.remove(drop)
+}
+
+// remove drops entries by ID from the pool and the database, the caller must
+// hold p.mu. Entries that fail to be deleted from the database are discarded
+// again the next time the pool is loaded.
+func (p *Pool) remove(drop map[string]bool) error {
+    if len(drop) == 0 {
+        return nil
+    }
+    
+    queue := make([]*Entry, 0, len(p.queue))
+    ids := make([]string, 0, len(drop))
+    for _, e := range p.queue {
+        if !drop[e.ID] {
+            queue = append(queue, e)
+            continue
+        }
+        
+        delete(p.byID, e.ID)
+        delete(p.byInquiry, e.InquiryID)
+        delete(p.byKey, e.PublicKey)
+        p.size -= len(e.Data)
+        ids = append(ids, e.ID)
+    }
+    p.queue = queue
+    
+    if err := p.db.DeletePoolEntries(ids); err != nil {
+        return fmt.Errorf("failed to delete pool entries: %w", err)
+    }
+    
+    return nil
+}
+
+// HasInquiry checks if a pending transaction uses an inquiry ID
+func (p *Pool) HasInquiry(inquiryID string) bool {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    
+    _, ok := p.byInquiry[inquiryID]
+    return ok
+}
+
+// Entries returns the unexpired pending transactions in arrival order
+func (p *Pool) Entries() []*Entry {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    
+    if err := p.expire(time.Now()); err != nil {
+        p.logger.Error("Failed to expire pool entries: %v", err)
+    }
+    
+    entries := make([]*Entry, len(p.queue))
+    copy(entries, p.queue)
+    return entries
+}
+
+// Stats returns the number and size of the unexpired pending transactions.
+// Expired entries are skipped but left for Add and Entries to drop.
+func (p *Pool) Stats() Stats {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    
+    now := time.Now()
+    stats := Stats{MaxSize: p.maxSize}
+    for _, e := range p.queue {
+        if now.Sub(e.Datetime) > p.maxAge {
+            continue
+        }
+        
+        if stats.Count == 0 {
+            stats.Oldest = e.Arrived
+        }
+        stats.Count++
+        stats.Size += len(e.Data)
+    }
+    
+    return stats
+}
\ No newline at end of file
@@ -0,0 +1,249 @@
+package mempool
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/storage"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+const testMaxAge = time.Hour
+
+// testEntry returns an entry whose data encodes its indexed fields
+func testEntry(id, inquiryID, publicKey string, datetime time.Time) *Entry {
+    return &Entry{
+        ID:        id,
+        InquiryID: inquiryID,
+        PublicKey: publicKey,
+        Datetime:  datetime,
+        Data:      []byte(strings.Join([]string{id, inquiryID, publicKey, strconv.FormatInt(datetime.UnixNano(), 10)}, "|")),
+    }
+}
+
+// decodeTestEntry is the DecodeFunc of the entries testEntry creates
+func decodeTestEntry(data []byte) (*Entry, error) {
+    fields := strings.Split(string(data), "|")
+    if len(fields) != 4 {
+        return nil, fmt.Errorf("malformed test entry %q", data)
+    }
+    nanos, err := strconv.ParseInt(fields[3], 10, 64)
+    if err != nil {
+        return nil, err
+    }
+    
+    return testEntry(fields[0], fields[1], fields[2], time.Unix(0, nanos)), nil
+}
+
+// newTestDB opens a database in a temporary directory
+func newTestDB(t *testing.T) *storage.Database {
+    t.Helper()
+    
+    db, err := storage.NewDatabase(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { db.Close() })
+    
+    return db
+}
+
+// newTestPool loads a pool from the database
+func newTestPool(t *testing.T, db *storage.Database, maxSize int) *Pool {
+    t.Helper()
+    
+    logger := utils.NewLogger(false)
+    logger.SetOutput(io.Discard)
+    
+    p, err := New(db, maxSize, testMaxAge, decodeTestEntry, logger)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return p
+}
+
+// entryIDs returns the IDs of the pending entries in arrival order
+func entryIDs(p *Pool) string {
+    var ids []string
+    for _, e := range p.Entries() {
+        ids = append(ids, e.ID)
+    }
+    return strings.Join(ids, ",")
+}
+
+func TestAdd(t *testing.T) {
+    now := time.Now()
+    pending := testEntry("tx-1", "inquiry-1", "key-1", now)
+    
+    tests := []struct {
+        name  string
+        entry *Entry
+        want  error
+    }{
+        {"new entry", testEntry("tx-2", "inquiry-2", "key-2", now), nil},
+        {"same ID", testEntry("tx-1", "inquiry-2", "key-2", now), ErrKnown},
+        {"same inquiry ID", testEntry("tx-2", "inquiry-1", "key-2", now), ErrDuplicateInquiry},
+        {"same public key", testEntry("tx-2", "inquiry-2", "key-1", now), ErrDuplicatePublicKey},
+        {"older than the maximum age", testEntry("tx-2", "inquiry-2", "key-2", now.Add(-testMaxAge-time.Minute)), ErrExpired},
+        {"larger than the space left", &Entry{ID: "tx-2", InquiryID: "inquiry-2", PublicKey: "key-2", Datetime: now, Data: make([]byte, 1000)}, ErrFull},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := newTestPool(t, newTestDB(t), 1000)
+            if err := p.Add(pending); err != nil {
+                t.Fatal(err)
+            }
+            
+            if err := p.Add(tt.entry); !errors.Is(err, tt.want) {
+                t.Fatalf("got %v, want %v", err, tt.want)
+            }
+            
+            want := "tx-1"
+            if tt.want == nil {
+                want = "tx-1,tx-2"
+            }
+            if got := entryIDs(p); got != want {
+                t.Fatalf("pending %s, want %s", got, want)
+            }
+        })
+    }
+}
+
+func TestExpiry(t *testing.T) {
+    db := newTestDB(t)
+    p := newTestPool(t, db, 1<<20)
+    
+    now := time.Now()
+    aging := testEntry("tx-1", "inquiry-1", "key-1", now)
+    for _, e := range []*Entry{aging, testEntry("tx-2", "inquiry-2", "key-2", now)} {
+        if err := p.Add(e); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    // Age the first entry past the limit
+    aging.Datetime = now.Add(-testMaxAge - time.Minute)
+    
+    // Stats skips it without dropping it
+    if stats := p.Stats(); stats.Count != 1 || stats.Size != len(p.byID["tx-2"].Data) {
+        t.Fatalf("stats %+v, want only tx-2", stats)
+    }
+    if _, ok := p.byID["tx-1"]; !ok {
+        t.Fatal("stats dropped an entry")
+    }
+    
+    // Entries drops it from the pool and the database
+    if got := entryIDs(p); got != "tx-2" {
+        t.Fatalf("pending %s, want tx-2", got)
+    }
+    if p.HasInquiry("inquiry-1") {
+        t.Fatal("expired entry is still indexed")
+    }
+    
+    stored, err := db.GetPoolEntries()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, ok := stored["tx-1"]; ok || len(stored) != 1 {
+        t.Fatalf("%d entries stored, want only tx-2", len(stored))
+    }
+}
+
+func TestRemove(t *testing.T) {
+    now := time.Now()
+    
+    tests := []struct {
+        name  string
+        mined *Entry
+        want  string
+    }{
+        {"by ID", &Entry{ID: "tx-1"}, "tx-2,tx-3"},
+        {"by inquiry ID", &Entry{ID: "other", InquiryID: "inquiry-2"}, "tx-1,tx-3"},
+        {"by public key", &Entry{ID: "other", PublicKey: "key-3"}, "tx-1,tx-2"},
+        {"unrelated transaction", &Entry{ID: "other", InquiryID: "inquiry-9", PublicKey: "key-9"}, "tx-1,tx-2,tx-3"},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db := newTestDB(t)
+            p := newTestPool(t, db, 1<<20)
+            for i := 1; i <= 3; i++ {
+                e := testEntry(fmt.Sprintf("tx-%d", i), fmt.Sprintf("inquiry-%d", i), fmt.Sprintf("key-%d", i), now)
+                if err := p.Add(e); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            
+            if err := p.Remove([]*Entry{tt.mined}); err != nil {
+                t.Fatal(err)
+            }
+            if got := entryIDs(p); got != tt.want {
+                t.Fatalf("pending %s, want %s", got, tt.want)
+            }
+            
+            // The database agrees after a reload
+            if got := entryIDs(newTestPool(t, db, 1<<20)); got != tt.want {
+                t.Fatalf("reloaded %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestReload(t *testing.T) {
+    db := newTestDB(t)
+    p := newTestPool(t, db, 1<<20)
+    
+    now := time.Now()
+    for i := 1; i <= 3; i++ {
+        e := testEntry(fmt.Sprintf("tx-%d", i), fmt.Sprintf("inquiry-%d", i), fmt.Sprintf("key-%d", i), now)
+        e.Arrived = now.Add(time.Duration(i) * time.Millisecond)
+        if err := p.Add(e); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    // Entries come back in arrival order with every field restored
+    reloaded := newTestPool(t, db, 1<<20)
+    if got := entryIDs(reloaded); got != "tx-1,tx-2,tx-3" {
+        t.Fatalf("reloaded %s, want tx-1,tx-2,tx-3", got)
+    }
+    for _, e := range reloaded.Entries() {
+        original := p.byID[e.ID]
+        if e.InquiryID != original.InquiryID || e.PublicKey != original.PublicKey ||
+            !e.Datetime.Equal(original.Datetime) || !e.Arrived.Equal(original.Arrived) {
+            t.Fatalf("reloaded %+v, want %+v", e, original)
+        }
+    }
+    
+    // Undecodable, misfiled, expired and oversized entries are discarded
+    bad := map[string][]byte{
+        "short":     {1, 2},
+        "malformed": append(make([]byte, 8), "not an entry"...),
+        "misfiled":  encodeEntry(testEntry("tx-9", "inquiry-9", "key-9", now)),
+        "tx-old":    encodeEntry(testEntry("tx-old", "inquiry-old", "key-old", now.Add(-2*testMaxAge))),
+    }
+    for id, data := range bad {
+        if err := db.SavePoolEntry(id, data); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    limit := len(p.byID["tx-1"].Data) + len(p.byID["tx-2"].Data)
+    if got := entryIDs(newTestPool(t, db, limit)); got != "tx-1,tx-2" {
+        t.Fatalf("reloaded %s, want tx-1,tx-2", got)
+    }
+    
+    stored, err := db.GetPoolEntries()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(stored) != 2 {
+        t.Fatalf("%d entries left in the database, want 2", len(stored))
+    }
+}
\ No newline at end of file
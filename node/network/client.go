@@ -2,10 +2,13 @@ package network
 
 import (
     "bytes"
+    "context"
     "encoding/json"
     "fmt"
     "io"
+    "math/big"
     "net/http"
+    "strconv"
     "time"
     
     "github.com/CertificationAgencyBlockchain/node/blockchain"
@@ -13,8 +16,11 @@ import (
 
 // Client handles outgoing network requests
 type Client struct {
-    httpClient *http.Client
-    timeout    time.Duration
+    httpClient   *http.Client
+    timeout      time.Duration
+    nodePort     int
+    magic        uint32
+    maxBlockSize int
 }
 
 // NewClient creates a new network client
@@ -66,48 +72,148 @@ func (c *Client) SubmitCertification(peerAddr string, tx *blockchain.Transaction
     return nil
 }
 
+// AnnounceBlock sends a block to a peer
+func (c *Client) AnnounceBlock(peerAddr string, block *blockchain.Block) error {
+    url := fmt.Sprintf("http://%s/api/v1/blocks", peerAddr)
+    
+    payload, err := block.Serialize()
+    if err != nil {
+        return fmt.Errorf("failed to encode block: %w", err)
+    }
+    
+    req, err := http.NewRequest("POST", url, bytes.NewReader(encodeMessage(c.magic, payload)))
+    if err != nil {
+        return fmt.Errorf("failed to create request: %w", err)
+    }
+    
+    req.Header.Set("Content-Type", binaryContentType)
+    if c.nodePort != 0 {
+        req.Header.Set("X-Node-Port", strconv.Itoa(c.nodePort))
+    }
+    
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("failed to send request: %w", err)
+    }
+    defer resp.Body.Close()
+    
+    if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+        body, _ := io.ReadAll(resp.Body)
+        return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+    }
+    
+    return nil
+}
+
 // GetBlock retrieves a block from a peer
 func (c *Client) GetBlock(peerAddr string, height uint64) (*blockchain.Block, error) {
     url := fmt.Sprintf("http://%s/api/v1/blocks/%d", peerAddr, height)
     
-    resp, err := c.httpClient.Get(url)
+    payload, err := c.getMessage(context.Background(), url, c.maxBlockSize)
     if err != nil {
         return nil, fmt.Errorf("failed to get block: %w", err)
     }
-    defer resp.Body.Close()
     
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("block not found")
+    block, err := blockchain.DeserializeBlock(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode block: %w", err)
+    }
+    
+    return block, nil
+}
+
+// GetBlockByHash retrieves a block by hash from a peer
+func (c *Client) GetBlockByHash(peerAddr, hash string) (*blockchain.Block, error) {
+    url := fmt.Sprintf("http://%s/api/v1/blocks/hash/%s", peerAddr, hash)
+    
+    payload, err := c.getMessage(context.Background(), url, c.maxBlockSize)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get block: %w", err)
     }
     
-    var block blockchain.Block
-    if err := json.NewDecoder(resp.Body).Decode(&block); err != nil {
+    block, err := blockchain.DeserializeBlock(payload)
+    if err != nil {
         return nil, fmt.Errorf("failed to decode block: %w", err)
     }
     
-    return &block, nil
+    return block, nil
+}
+
+// GetBlocks retrieves consecutive blocks starting at a height from a peer
+func (c *Client) GetBlocks(ctx context.Context, peerAddr string, from uint64, count int) ([]*blockchain.Block, error) {
+    url := fmt.Sprintf("http://%s/api/v1/blocks/range?from=%d&count=%d", peerAddr, from, count)
+    
+    payload, err := c.getMessage(ctx, url, blockListOverhead+count*c.maxBlockSize)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get blocks: %w", err)
+    }
+    
+    blocks, err := blockchain.DeserializeBlocks(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode blocks: %w", err)
+    }
+    
+    return blocks, nil
+}
+
+// GetHeaders retrieves consecutive block headers starting at a height from a peer
+func (c *Client) GetHeaders(peerAddr string, from uint64, count int) ([]blockchain.BlockHeader, error) {
+    url := fmt.Sprintf("http://%s/api/v1/headers?from=%d&count=%d", peerAddr, from, count)
+    
+    payload, err := c.getMessage(context.Background(), url, maxMessageSize)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get headers: %w", err)
+    }
+    
+    headers, err := blockchain.DeserializeHeaders(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode headers: %w", err)
+    }
+    
+    return headers, nil
 }
 
 // GetLatestBlock retrieves the latest block from a peer
 func (c *Client) GetLatestBlock(peerAddr string) (*blockchain.Block, error) {
     url := fmt.Sprintf("http://%s/api/v1/blocks/latest", peerAddr)
     
-    resp, err := c.httpClient.Get(url)
+    payload, err := c.getMessage(context.Background(), url, c.maxBlockSize)
     if err != nil {
         return nil, fmt.Errorf("failed to get latest block: %w", err)
     }
+    
+    block, err := blockchain.DeserializeBlock(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode block: %w", err)
+    }
+    
+    return block, nil
+}
+
+// getMessage requests a resource from a peer in the binary encoding and returns a
+// payload of at most maxSize bytes
+func (c *Client) getMessage(ctx context.Context, url string, maxSize int) ([]byte, error) {
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+    req.Header.Set("Accept", binaryContentType)
+    
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        return nil, err
+    }
     defer resp.Body.Close()
     
     if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("latest block not found")
+        return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
     }
     
-    var block blockchain.Block
-    if err := json.NewDecoder(resp.Body).Decode(&block); err != nil {
-        return nil, fmt.Errorf("failed to decode block: %w", err)
+    if contentType := resp.Header.Get("Content-Type"); contentType != binaryContentType {
+        return nil, fmt.Errorf("unexpected content type %q", contentType)
     }
     
-    return &block, nil
+    return decodeMessage(c.magic, resp.Body, maxSize)
 }
 
 // GetPeers retrieves the peer list from a node
@@ -183,32 +289,109 @@ func (c *Client) BroadcastTransaction(peers []string, tx *blockchain.Transaction
     return nil
 }
 
-// SyncBlockchain synchronizes the blockchain with a peer
-func (c *Client) SyncBlockchain(peerAddr string, currentHeight uint64) ([]*blockchain.Block, error) {
+// BroadcastBlock announces a block to multiple peers
+func (c *Client) BroadcastBlock(peers []string, block *blockchain.Block) error {
+    errChan := make(chan error, len(peers))
+    
+    for _, peer := range peers {
+        go func(peerAddr string) {
+            errChan <- c.AnnounceBlock(peerAddr, block)
+        }(peer)
+    }
+    
+    // Wait for all announcements to complete
+    var lastErr error
+    successCount := 0
+    
+    for i := 0; i < len(peers); i++ {
+        if err := <-errChan; err != nil {
+            lastErr = err
+        } else {
+            successCount++
+        }
+    }
+    
+    if successCount == 0 && lastErr != nil {
+        return fmt.Errorf("failed to announce to any peer: %w", lastErr)
+    }
+    
+    return nil
+}
+
+// SyncBlockchain downloads the blocks a peer has above currentHeight and passes
+// them in order to handle, stopping at the first error
+func (c *Client) SyncBlockchain(peerAddr string, currentHeight uint64, handle func(*blockchain.Block) error) error {
     // Get peer's latest block
     latestBlock, err := c.GetLatestBlock(peerAddr)
     if err != nil {
-        return nil, fmt.Errorf("failed to get peer's latest block: %w", err)
+        return fmt.Errorf("failed to get peer's latest block: %w", err)
     }
     
     if latestBlock.Header.Height <= currentHeight {
         // Nothing to sync
-        return nil, nil
+        return nil
     }
     
     // Download missing blocks
-    blocks := make([]*blockchain.Block, 0)
-    
     for height := currentHeight + 1; height <= latestBlock.Header.Height; height++ {
         block, err := c.GetBlock(peerAddr, height)
         if err != nil {
-            return nil, fmt.Errorf("failed to get block %d: %w", height, err)
+            return fmt.Errorf("failed to get block %d: %w", height, err)
         }
         
-        blocks = append(blocks, block)
+        if err := handle(block); err != nil {
+            return err
+        }
     }
     
-    return blocks, nil
+    return nil
+}
+
+// PeerChainState is the chain state and clock a peer reports in its health check
+type PeerChainState struct {
+    Height    uint64
+    ChainWork *big.Int
+    Time      time.Time
+}
+
+// GetPeerChainState returns the chain height, cumulative work and current time a peer reports in its health check
+func (c *Client) GetPeerChainState(peerAddr string) (*PeerChainState, error) {
+    health, err := c.GetHealth(peerAddr)
+    if err != nil {
+        return nil, err
+    }
+    
+    chain, ok := health["blockchain"].(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("health response has no blockchain section")
+    }
+    
+    height, ok := chain["height"].(float64)
+    if !ok {
+        return nil, fmt.Errorf("health response has no height")
+    }
+    
+    workHex, ok := chain["chain_work"].(string)
+    if !ok {
+        return nil, fmt.Errorf("health response has no chain work")
+    }
+    
+    work, ok := new(big.Int).SetString(workHex, 16)
+    if !ok {
+        return nil, fmt.Errorf("invalid chain work %q", workHex)
+    }
+    
+    timestamp, _ := health["timestamp"].(string)
+    peerTime, err := time.Parse(time.RFC3339Nano, timestamp)
+    if err != nil {
+        return nil, fmt.Errorf("health response has an invalid timestamp: %w", err)
+    }
+    
+    return &PeerChainState{
+        Height:    uint64(height),
+        ChainWork: work,
+        Time:      peerTime,
+    }, nil
 }
 
 // QueryCertificationByPublicKey queries a certification by public key
@@ -163,8 +163,9 @@ func (d *Discovery) queryPeerForNodes(peerAddr string) {
     for _, peer := range peers {
         if !d.isKnownPeer(peer.Address) {
             d.addKnownPeer(peer.Address)
-            d.server.AddPeer(peer.Address)
-            d.logger.Info("Discovered new peer: %s", peer.Address)
+            if d.server.AddPeer(peer.Address) {
+                d.logger.Info("Discovered new peer: %s", peer.Address)
+            }
         }
     }
 }
@@ -0,0 +1,204 @@
+package network
+
+import (
+    "context"
+    "fmt"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+const (
+    // maxHeadersPerRequest is the maximum number of headers served per request
+    maxHeadersPerRequest = 2000
+    
+    // maxBlocksPerRequest is the maximum number of blocks served per request
+    maxBlocksPerRequest = 100
+    
+    // blockWindowSize is the number of blocks requested from a peer at once
+    blockWindowSize = 16
+    
+    // maxWindowsAhead limits how far downloads may run ahead of the connected chain
+    maxWindowsAhead = 32
+    
+    // blockStallTimeout is how long a window request may take before it is reassigned
+    blockStallTimeout = 15 * time.Second
+    
+    // maxPeerStrikes is how many failed windows a peer may have before it is dropped
+    maxPeerStrikes = 3
+)
+
+// blockWindow is a range of consecutive blocks assigned to one peer at a time
+type blockWindow struct {
+    index   int
+    headers []blockchain.BlockHeader
+}
+
+// windowResult is the outcome of a window download or a peer giving up
+type windowResult struct {
+    window *blockWindow
+    blocks []*blockchain.Block
+    peer   string
+    err    error
+}
+
+// blockFetcher downloads block bodies for validated headers from several peers in parallel
+type blockFetcher struct {
+    client       syncClient
+    logger       *utils.Logger
+    stallTimeout time.Duration
+}
+
+// newBlockFetcher creates a new block fetcher
+func newBlockFetcher(client syncClient, logger *utils.Logger) *blockFetcher {
+    return &blockFetcher{
+        client:       client,
+        logger:       logger,
+        stallTimeout: blockStallTimeout,
+    }
+}
+
+// fetch downloads the bodies for headers from peers and passes the blocks to handle in chain order
+func (f *blockFetcher) fetch(ctx context.Context, peers []string, headers []blockchain.BlockHeader, handle func(*blockchain.Block) error) error {
+    if len(headers) == 0 {
+        return nil
+    }
+    
+    if len(peers) == 0 {
+        return fmt.Errorf("no peers to download blocks from")
+    }
+    
+    // Split headers into windows
+    var windows []*blockWindow
+    for start := 0; start < len(headers); start += blockWindowSize {
+        end := start + blockWindowSize
+        if end > len(headers) {
+            end = len(headers)
+        }
+        windows = append(windows, &blockWindow{index: len(windows), headers: headers[start:end]})
+    }
+    
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    
+    // Every window is in the queue at most once, so sends never block
+    queue := make(chan *blockWindow, len(windows))
+    results := make(chan windowResult, len(peers))
+    
+    queued := 0
+    for ; queued < len(windows) && queued < maxWindowsAhead; queued++ {
+        queue <- windows[queued]
+    }
+    
+    for _, peer := range peers {
+        go f.worker(ctx, peer, queue, results)
+    }
+    
+    pending := make(map[int][]*blockchain.Block)
+    failedPeers := 0
+    next := 0
+    
+    for next < len(windows) {
+        var res windowResult
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case res = <-results:
+        }
+        
+        if res.err != nil {
+            failedPeers++
+            f.logger.Warn("Dropped peer %s from block download: %v", res.peer, res.err)
+            if failedPeers == len(peers) {
+                return fmt.Errorf("all peers failed: %w", res.err)
+            }
+            continue
+        }
+        
+        pending[res.window.index] = res.blocks
+        
+        // Hand over windows in order as soon as they are complete
+        for blocks, ok := pending[next]; ok; blocks, ok = pending[next] {
+            for _, block := range blocks {
+                if err := handle(block); err != nil {
+                    return err
+                }
+            }
+            
+            delete(pending, next)
+            next++
+            
+            if queued < len(windows) {
+                queue <- windows[queued]
+                queued++
+            }
+        }
+    }
+    
+    return nil
+}
+
+// worker downloads windows from a single peer, returning failed windows to the queue
+func (f *blockFetcher) worker(ctx context.Context, peer string, queue chan *blockWindow, results chan<- windowResult) {
+    strikes := 0
+    
+    for {
+        var window *blockWindow
+        select {
+        case <-ctx.Done():
+            return
+        case window = <-queue:
+        }
+        
+        blocks, err := f.fetchWindow(ctx, peer, window)
+        if err != nil {
+            // Reassign the window to another peer
+            queue <- window
+            
+            strikes++
+            f.logger.Debug("Window %d from %s failed (%d/%d): %v", window.index, peer, strikes, maxPeerStrikes, err)
+            
+            if strikes >= maxPeerStrikes {
+                select {
+                case results <- windowResult{peer: peer, err: err}:
+                case <-ctx.Done():
+                }
+                return
+            }
+            continue
+        }
+        
+        strikes = 0
+        select {
+        case results <- windowResult{window: window, blocks: blocks, peer: peer}:
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// fetchWindow downloads one window and checks the blocks against their headers
+func (f *blockFetcher) fetchWindow(ctx context.Context, peer string, window *blockWindow) ([]*blockchain.Block, error) {
+    // Requests that stall are cancelled so the window can move to a faster peer
+    ctx, cancel := context.WithTimeout(ctx, f.stallTimeout)
+    defer cancel()
+    
+    first := window.headers[0].Height
+    blocks, err := f.client.GetBlocks(ctx, peer, first, len(window.headers))
+    if err != nil {
+        return nil, err
+    }
+    
+    if len(blocks) != len(window.headers) {
+        return nil, fmt.Errorf("expected %d blocks from height %d, got %d", len(window.headers), first, len(blocks))
+    }
+    
+    for i, block := range blocks {
+        if block.Hash() != window.headers[i].Hash() {
+            return nil, fmt.Errorf("block %d does not match its header", window.headers[i].Height)
+        }
+    }
+    
+    return blocks, nil
+}
\ No newline at end of file
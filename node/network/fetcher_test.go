@@ -0,0 +1,112 @@
+package network
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+)
+
+func TestBlockFetcher(t *testing.T) {
+    // Three windows, the last one partly filled
+    source := newTestChain(t)
+    generate(t, source, 2*blockWindowSize+5, false)
+    headers := source.GetHeaders(1, int(source.GetHeight()))
+    
+    failing := errors.New("connection refused")
+    
+    tests := []struct {
+        name     string
+        peers    map[string]*stubPeer
+        wantErr  bool
+        maxCalls map[string]int
+    }{
+        {"single peer", map[string]*stubPeer{
+            "good": {chain: source},
+        }, false, nil},
+        {"several peers", map[string]*stubPeer{
+            "first": {chain: source}, "second": {chain: source}, "third": {chain: source},
+        }, false, nil},
+        {"failing peer is dropped", map[string]*stubPeer{
+            "good": {chain: source}, "bad": {chain: source, err: failing},
+        }, false, map[string]int{"bad": maxPeerStrikes}},
+        {"blocks that do not match the headers", map[string]*stubPeer{
+            "good": {chain: source}, "wrong": {chain: source, shifted: true},
+        }, false, map[string]int{"wrong": maxPeerStrikes}},
+        {"stalled windows are reassigned", map[string]*stubPeer{
+            "good": {chain: source}, "slow": {chain: source, stall: true},
+        }, false, map[string]int{"slow": maxPeerStrikes}},
+        {"all peers fail", map[string]*stubPeer{
+            "bad": {chain: source, err: failing}, "wrong": {chain: source, shifted: true},
+        }, true, map[string]int{"bad": maxPeerStrikes, "wrong": maxPeerStrikes}},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            client := newStubClient(tt.peers)
+            fetcher := newBlockFetcher(client, testLogger())
+            fetcher.stallTimeout = 50 * time.Millisecond
+            
+            var peers []string
+            for address := range tt.peers {
+                peers = append(peers, address)
+            }
+            
+            var got []*blockchain.Block
+            err := fetcher.fetch(context.Background(), peers, headers, func(block *blockchain.Block) error {
+                got = append(got, block)
+                return nil
+            })
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("got error %v, want an error %v", err, tt.wantErr)
+            }
+            
+            for address, max := range tt.maxCalls {
+                if calls := client.callCount(address); calls > max {
+                    t.Fatalf("%s was asked for %d windows, want at most %d", address, calls, max)
+                }
+            }
+            
+            if tt.wantErr {
+                return
+            }
+            
+            // Blocks are handed over once each, in chain order
+            if len(got) != len(headers) {
+                t.Fatalf("handled %d blocks, want %d", len(got), len(headers))
+            }
+            for i, block := range got {
+                if block.Hash() != headers[i].Hash() {
+                    t.Fatalf("block %d is out of order", i)
+                }
+            }
+        })
+    }
+}
+
+func TestBlockFetcherStopsOnHandlerError(t *testing.T) {
+    source := newTestChain(t)
+    generate(t, source, blockWindowSize+1, false)
+    headers := source.GetHeaders(1, int(source.GetHeight()))
+    
+    fetcher := newBlockFetcher(newStubClient(map[string]*stubPeer{"good": {chain: source}}), testLogger())
+    
+    rejected := errors.New("invalid block")
+    handled := 0
+    err := fetcher.fetch(context.Background(), []string{"good"}, headers, func(block *blockchain.Block) error {
+        handled++
+        if handled == 3 {
+            return rejected
+        }
+        return nil
+    })
+    
+    if !errors.Is(err, rejected) {
+        t.Fatalf("got %v, want %v", err, rejected)
+    }
+    if handled != 3 {
+        t.Fatalf("handled %d blocks after the error, want 3", handled)
+    }
+}
\ No newline at end of file
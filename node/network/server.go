@@ -3,20 +3,27 @@ package network
 import (
     "context"
     "encoding/json"
+    "errors"
     "fmt"
+    "math/big"
     "net"
     "net/http"
+    "strconv"
     "sync"
     "time"
     
     "github.com/CertificationAgencyBlockchain/node/api"
     "github.com/CertificationAgencyBlockchain/node/blockchain"
     "github.com/CertificationAgencyBlockchain/node/config"
+    "github.com/CertificationAgencyBlockchain/node/mempool"
     "github.com/CertificationAgencyBlockchain/node/storage"
     "github.com/CertificationAgencyBlockchain/node/utils"
     "github.com/gorilla/mux"
 )
 
+// maxGenerateBlocks is the maximum number of blocks generated per admin request
+const maxGenerateBlocks = 1000
+
 // Server represents the network server
 type Server struct {
     config       *config.Config
@@ -24,10 +31,16 @@ type Server struct {
     db           *storage.Database
     logger       *utils.Logger
     router       *mux.Router
+    adminRouter  *mux.Router
     httpServer   *http.Server
+    adminServer  *http.Server
     udpConn      *net.UDPConn
     peers        map[string]*Peer
     peersMu      sync.RWMutex
+    client       *Client
+    syncManager  *SyncManager
+    fetching     map[string]bool
+    fetchingMu   sync.Mutex
     personaClient interface {
         VerifyIdentity(inquiryID string, expectedName, expectedSurname string) (*api.VerificationResult, error)
     }
@@ -39,6 +52,7 @@ type Peer struct {
     LastSeen   time.Time `json:"last_seen"`
     Version    string    `json:"version"`
     Height     uint64    `json:"height"`
+    ChainWork  string    `json:"chain_work,omitempty"`
 }
 
 // NewServer creates a new network server
@@ -49,6 +63,8 @@ func NewServer(cfg *config.Config, bc *blockchain.Blockchain, db *storage.Databa
         db:         db,
         logger:     logger,
         peers:      make(map[string]*Peer),
+        client:     NewClient(cfg.Network.Timeout),
+        fetching:   make(map[string]bool),
     }
     
     // Initialize Persona client
@@ -59,6 +75,14 @@ func NewServer(cfg *config.Config, bc *blockchain.Blockchain, db *storage.Databa
         s.personaClient = api.NewMockPersonaClient()
     }
     
+    // Announce blocks mined by this node to all peers
+    s.client.nodePort = cfg.Network.Port
+    s.client.magic = cfg.Blockchain.MagicValue
+    s.client.maxBlockSize = cfg.Blockchain.MaxBlockSize
+    bc.SetBlockMinedHandler(func(block *blockchain.Block) {
+        s.BroadcastBlock(block, "")
+    })
+    
     // Setup routes
     s.setupRoutes()
     
@@ -83,11 +107,20 @@ func (s *Server) setupRoutes() {
     api.HandleFunc("/certifications", s.handleSubmitCertification).Methods("POST", "OPTIONS")
     api.HandleFunc("/certifications/by-public-key/{publicKey}", s.handleGetByPublicKey).Methods("GET")
     api.HandleFunc("/certifications/by-identity", s.handleGetByIdentity).Methods("GET")
+    api.HandleFunc("/certifications/{txid:[0-9a-f]{64}}/proof", s.handleGetProof).Methods("GET")
+    api.HandleFunc("/state/{fingerprint:[0-9a-f]{64}}", s.handleGetCertificationStatus).Methods("GET")
     
     // Blockchain endpoints
     api.HandleFunc("/blocks", s.handleGetBlocks).Methods("GET")
-    api.HandleFunc("/blocks/{height}", s.handleGetBlock).Methods("GET")
+    api.HandleFunc("/blocks", s.handleAnnounceBlock).Methods("POST")
     api.HandleFunc("/blocks/latest", s.handleGetLatestBlock).Methods("GET")
+    api.HandleFunc("/blocks/range", s.handleGetBlockRange).Methods("GET")
+    api.HandleFunc("/blocks/{height:[0-9]+}", s.handleGetBlock).Methods("GET")
+    api.HandleFunc("/blocks/hash/{hash}", s.handleGetBlockByHash).Methods("GET")
+    api.HandleFunc("/headers", s.handleGetHeaders).Methods("GET")
+    
+    // Authority endpoints
+    api.HandleFunc("/authorities", s.handleGetAuthorities).Methods("GET")
     
     // Network endpoints
     api.HandleFunc("/peers", s.handleGetPeers).Methods("GET")
@@ -95,6 +128,16 @@ func (s *Server) setupRoutes() {
     
     // Health check
     api.HandleFunc("/health", s.handleHealthCheck).Methods("GET")
+    
+    // Operator endpoints change what this node seals and are only served on the loopback admin listener
+    s.adminRouter = mux.NewRouter()
+    admin := s.adminRouter.PathPrefix("/api/v1").Subrouter()
+    admin.HandleFunc("/authorities/votes", s.handleProposeAuthority).Methods("POST")
+    
+    // Blocks are only generated on request on networks without background mining
+    if s.blockchain.Params().OnDemandMining {
+        admin.HandleFunc("/admin/generate", s.handleGenerateBlocks).Methods("POST")
+    }
 }
 
 // corsMiddleware adds CORS headers to all responses
@@ -102,7 +145,7 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         w.Header().Set("Access-Control-Allow-Origin", "*")
         w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Client-ID, X-Network-Flag")
+        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Client-ID, X-Network-Flag, X-Node-Port")
         
         if r.Method == "OPTIONS" {
             w.WriteHeader(http.StatusOK)
@@ -128,6 +171,21 @@ func (s *Server) Start(ctx context.Context) error {
         }
     }()
     
+    // Start the admin server on the loopback interface only
+    if s.config.API.AdminPort != 0 {
+        s.adminServer = &http.Server{
+            Addr:    fmt.Sprintf("127.0.0.1:%d", s.config.API.AdminPort),
+            Handler: s.adminRouter,
+        }
+        
+        go func() {
+            s.logger.Info("Admin server listening on %s", s.adminServer.Addr)
+            if err := s.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+                s.logger.Error("Admin server error: %v", err)
+            }
+        }()
+    }
+    
     // Start UDP listener for flag detection
     go s.startUDPListener(ctx)
     
@@ -145,6 +203,12 @@ func (s *Server) Stop() {
         s.httpServer.Shutdown(ctx)
     }
     
+    if s.adminServer != nil {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        s.adminServer.Shutdown(ctx)
+    }
+    
     if s.udpConn != nil {
         s.udpConn.Close()
     }
@@ -260,6 +324,11 @@ func (s *Server) handleSubmitCertification(w http.ResponseWriter, r *http.Reques
         return
     }
     
+    if err := tx.ValidateFreshness(s.blockchain.AdjustedTime(), s.config.Security.MaxInquiryAge); err != nil {
+        http.Error(w, fmt.Sprintf("Invalid transaction: %v", err), http.StatusBadRequest)
+        return
+    }
+    
     // Verify signature
     if err := tx.VerifySignature(); err != nil {
         http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusBadRequest)
@@ -280,7 +349,11 @@ func (s *Server) handleSubmitCertification(w http.ResponseWriter, r *http.Reques
     
     // Add to blockchain mining pool
     if err := s.blockchain.AddTransaction(tx); err != nil {
-        http.Error(w, fmt.Sprintf("Failed to add transaction: %v", err), http.StatusInternalServerError)
+        status := http.StatusInternalServerError
+        if errors.Is(err, mempool.ErrFull) {
+            status = http.StatusServiceUnavailable
+        }
+        http.Error(w, fmt.Sprintf("Failed to add transaction: %v", err), status)
         return
     }
     
@@ -329,6 +402,45 @@ func (s *Server) handleGetByIdentity(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(cert)
 }
 
+// handleGetProof handles requests for the Merkle proof that a certification is in a main chain block
+func (s *Server) handleGetProof(w http.ResponseWriter, r *http.Request) {
+    txID := mux.Vars(r)["txid"]
+    
+    proof, err := s.blockchain.GetCertificationProof(txID)
+    if err != nil {
+        http.Error(w, "Certification not found", http.StatusNotFound)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(proof)
+}
+
+// handleGetCertificationStatus handles requests for the state proof of whether a public key
+// fingerprint is certified, at the chain tip or at the main chain block given by height
+func (s *Server) handleGetCertificationStatus(w http.ResponseWriter, r *http.Request) {
+    fingerprint := mux.Vars(r)["fingerprint"]
+    
+    height := s.blockchain.GetHeight()
+    if value := r.URL.Query().Get("height"); value != "" {
+        parsed, err := strconv.ParseUint(value, 10, 64)
+        if err != nil {
+            http.Error(w, "Invalid height", http.StatusBadRequest)
+            return
+        }
+        height = parsed
+    }
+    
+    status, err := s.blockchain.GetCertificationStatus(fingerprint, height)
+    if err != nil {
+        http.Error(w, "Block not found", http.StatusNotFound)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(status)
+}
+
 // handleGetBlocks handles getting all blocks
 func (s *Server) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
     blocks := s.blockchain.GetAllBlocks()
@@ -337,6 +449,64 @@ func (s *Server) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(blocks)
 }
 
+// handleAnnounceBlock handles a block announced by a peer
+func (s *Server) handleAnnounceBlock(w http.ResponseWriter, r *http.Request) {
+    if r.Header.Get("Content-Type") != binaryContentType {
+        http.Error(w, "Blocks must be sent in the binary encoding", http.StatusUnsupportedMediaType)
+        return
+    }
+    
+    payload, err := decodeMessage(s.config.Blockchain.MagicValue, r.Body, s.config.Blockchain.MaxBlockSize)
+    if err != nil {
+        http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+        return
+    }
+    
+    block, err := blockchain.DeserializeBlock(payload)
+    if err != nil {
+        http.Error(w, fmt.Sprintf("Invalid block: %v", err), http.StatusBadRequest)
+        return
+    }
+    
+    if err := block.Validate(); err != nil {
+        http.Error(w, fmt.Sprintf("Invalid block: %v", err), http.StatusBadRequest)
+        return
+    }
+    
+    peerAddr := peerAddress(r)
+    
+    status := "accepted"
+    code := http.StatusOK
+    
+    err = s.ProcessPeerBlock(peerAddr, block)
+    switch {
+    case err == nil:
+        // Only a node that announced a valid new block becomes a peer
+        if peerAddr != "" {
+            s.AddPeer(peerAddr)
+        }
+        
+        // Relay the new block to the rest of the network
+        s.BroadcastBlock(block, peerAddr)
+    case errors.Is(err, blockchain.ErrBlockKnown):
+        status = "known"
+    case errors.Is(err, blockchain.ErrOrphanBlock):
+        status = "orphan"
+        code = http.StatusAccepted
+    default:
+        http.Error(w, fmt.Sprintf("Block rejected: %v", err), http.StatusBadRequest)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "success": true,
+        "status":  status,
+        "hash":    block.Hash(),
+    })
+}
+
 // handleGetBlock handles getting a specific block
 func (s *Server) handleGetBlock(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
@@ -349,10 +519,96 @@ func (s *Server) handleGetBlock(w http.ResponseWriter, r *http.Request) {
         return
     }
     
+    if wantsBinary(r) {
+        data, err := block.Serialize()
+        s.writeMessage(w, data, err)
+        return
+    }
+    
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(block)
 }
 
+// handleGetBlockByHash handles getting a block by hash
+func (s *Server) handleGetBlockByHash(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    
+    block, err := s.blockchain.GetBlockByHash(vars["hash"])
+    if err != nil {
+        http.Error(w, "Block not found", http.StatusNotFound)
+        return
+    }
+    
+    if wantsBinary(r) {
+        data, err := block.Serialize()
+        s.writeMessage(w, data, err)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(block)
+}
+
+// handleGetBlockRange handles getting consecutive blocks for peer sync
+func (s *Server) handleGetBlockRange(w http.ResponseWriter, r *http.Request) {
+    from, count, err := parseRange(r, maxBlocksPerRequest)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    
+    blocks := s.blockchain.GetBlocks(from, count)
+    if wantsBinary(r) {
+        data, err := blockchain.SerializeBlocks(blocks)
+        s.writeMessage(w, data, err)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(blocks)
+}
+
+// handleGetHeaders handles getting consecutive block headers for peer sync
+func (s *Server) handleGetHeaders(w http.ResponseWriter, r *http.Request) {
+    from, count, err := parseRange(r, maxHeadersPerRequest)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    
+    headers := s.blockchain.GetHeaders(from, count)
+    if wantsBinary(r) {
+        data, err := blockchain.SerializeHeaders(headers)
+        s.writeMessage(w, data, err)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(headers)
+}
+
+// parseRange parses the from and count query parameters, capping count at max
+func parseRange(r *http.Request, max int) (uint64, int, error) {
+    from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+    if err != nil {
+        return 0, 0, fmt.Errorf("invalid from parameter")
+    }
+    
+    count := max
+    if param := r.URL.Query().Get("count"); param != "" {
+        count, err = strconv.Atoi(param)
+        if err != nil || count < 1 {
+            return 0, 0, fmt.Errorf("invalid count parameter")
+        }
+    }
+    
+    if count > max {
+        count = max
+    }
+    
+    return from, count, nil
+}
+
 // handleGetLatestBlock handles getting the latest block
 func (s *Server) handleGetLatestBlock(w http.ResponseWriter, r *http.Request) {
     block := s.blockchain.GetLatestBlock()
@@ -361,6 +617,12 @@ func (s *Server) handleGetLatestBlock(w http.ResponseWriter, r *http.Request) {
         return
     }
     
+    if wantsBinary(r) {
+        data, err := block.Serialize()
+        s.writeMessage(w, data, err)
+        return
+    }
+    
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(block)
 }
@@ -407,7 +669,10 @@ func (s *Server) handleAddPeer(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    s.AddPeer(peer.Address)
+    if !s.AddPeer(peer.Address) {
+        http.Error(w, fmt.Sprintf("Peer limit of %d reached", s.config.Network.MaxPeers), http.StatusServiceUnavailable)
+        return
+    }
     
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(map[string]interface{}{
@@ -416,12 +681,99 @@ func (s *Server) handleAddPeer(w http.ResponseWriter, r *http.Request) {
     })
 }
 
+// handleGetAuthorities handles requests for the current proof of authority signers
+func (s *Server) handleGetAuthorities(w http.ResponseWriter, r *http.Request) {
+    authorities, err := s.blockchain.GetAuthorities()
+    if err != nil {
+        if errors.Is(err, blockchain.ErrNotAuthorityChain) {
+            http.Error(w, err.Error(), http.StatusNotFound)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "authorities": authorities,
+        "count":       len(authorities),
+    })
+}
+
+// handleProposeAuthority handles votes to add or remove a proof of authority signer
+func (s *Server) handleProposeAuthority(w http.ResponseWriter, r *http.Request) {
+    var req struct {
+        PublicKey string `json:"public_key"`
+        Authorize bool   `json:"authorize"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    if err := s.blockchain.ProposeAuthority(req.PublicKey, req.Authorize); err != nil {
+        if errors.Is(err, blockchain.ErrNotAuthorityChain) {
+            http.Error(w, err.Error(), http.StatusNotFound)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "success": true,
+        "message": "Vote will be cast in the next sealed block",
+    })
+}
+
+// handleGenerateBlocks mines blocks on demand on regtest style networks
+func (s *Server) handleGenerateBlocks(w http.ResponseWriter, r *http.Request) {
+    req := struct {
+        Blocks int `json:"blocks"`
+    }{Blocks: 1}
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+    
+    if req.Blocks < 1 || req.Blocks > maxGenerateBlocks {
+        http.Error(w, fmt.Sprintf("blocks must be between 1 and %d", maxGenerateBlocks), http.StatusBadRequest)
+        return
+    }
+    
+    blocks, err := s.blockchain.GenerateBlocks(r.Context(), req.Blocks)
+    if err != nil {
+        if errors.Is(err, blockchain.ErrOnDemandMiningDisabled) {
+            http.Error(w, err.Error(), http.StatusForbidden)
+            return
+        }
+        s.logger.Warn("Generated %d of %d blocks: %v", len(blocks), req.Blocks, err)
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    hashes := make([]string, 0, len(blocks))
+    for _, block := range blocks {
+        hashes = append(hashes, block.Hash())
+    }
+    
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "blocks": hashes,
+        "height": s.blockchain.GetHeight(),
+    })
+}
+
 // handleHealthCheck handles health check requests
 func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
     health := map[string]interface{}{
         "status": "healthy",
         "blockchain": map[string]interface{}{
             "height": s.blockchain.GetHeight(),
+            "chain_work": s.blockchain.GetChainWork().Text(16),
             "latest_hash": func() string {
                 if block := s.blockchain.GetLatestBlock(); block != nil {
                     return block.Hash()
@@ -429,6 +781,12 @@ func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
                 return ""
             }(),
         },
+        "mining": map[string]interface{}{
+            "enabled":  s.config.Mining.Enabled,
+            "threads":  s.config.Mining.Threads,
+            "hashrate": s.blockchain.Hashrate(),
+        },
+        "mempool": s.blockchain.Mempool().Stats(),
         "network": map[string]interface{}{
             "peer_count": len(s.peers),
             "network_id": s.config.Network.NetworkID,
@@ -436,21 +794,146 @@ func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
         "timestamp": time.Now(),
     }
     
+    if s.syncManager != nil {
+        health["sync"] = s.syncManager.Status()
+    }
+    
     w.Header().Set("Content-Type", "application/json")
     json.NewEncoder(w).Encode(health)
 }
 
-// AddPeer adds a new peer
-func (s *Server) AddPeer(address string) {
+// ProcessPeerBlock adds a block received from a peer. When the block is an orphan
+// its missing parents are requested from the same peer in the background.
+func (s *Server) ProcessPeerBlock(peerAddr string, block *blockchain.Block) error {
+    err := s.blockchain.AddBlock(block)
+    if errors.Is(err, blockchain.ErrOrphanBlock) && peerAddr != "" {
+        go s.fetchMissingParents(peerAddr, block.Hash())
+    }
+    
+    return err
+}
+
+// BroadcastBlock announces a block to every peer except the one it came from
+func (s *Server) BroadcastBlock(block *blockchain.Block, except string) {
+    peers := make([]string, 0)
+    for _, peer := range s.GetPeers() {
+        if peer.Address != except {
+            peers = append(peers, peer.Address)
+        }
+    }
+    
+    if len(peers) == 0 {
+        return
+    }
+    
+    s.logger.Info("Announcing block %d to %d peers", block.Header.Height, len(peers))
+    
+    go func() {
+        if err := s.client.BroadcastBlock(peers, block); err != nil {
+            s.logger.Warn("Failed to announce block %s: %v", block.Hash(), err)
+        }
+    }()
+}
+
+// peerAddress returns the listening address of the node that sent a request
+func peerAddress(r *http.Request) string {
+    port := r.Header.Get("X-Node-Port")
+    if port == "" {
+        return ""
+    }
+    
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return ""
+    }
+    
+    return net.JoinHostPort(host, port)
+}
+
+// fetchMissingParents walks back from an orphan and downloads its unknown ancestors from a peer
+func (s *Server) fetchMissingParents(peerAddr, orphanHash string) {
+    missing := s.blockchain.GetMissingParent(orphanHash)
+    if missing == "" {
+        return
+    }
+    
+    // Avoid requesting the same ancestors several times at once
+    s.fetchingMu.Lock()
+    if s.fetching[missing] {
+        s.fetchingMu.Unlock()
+        return
+    }
+    s.fetching[missing] = true
+    s.fetchingMu.Unlock()
+    
+    defer func() {
+        s.fetchingMu.Lock()
+        delete(s.fetching, missing)
+        s.fetchingMu.Unlock()
+    }()
+    
+    for missing != "" && !s.blockchain.HaveBlock(missing) {
+        s.logger.Debug("Requesting missing parent %s from %s", missing, peerAddr)
+        
+        parent, err := s.client.GetBlockByHash(peerAddr, missing)
+        if err != nil {
+            s.logger.Warn("Failed to fetch missing parent %s from %s: %v", missing, peerAddr, err)
+            return
+        }
+        
+        if parent.Hash() != missing {
+            s.logger.Warn("Peer %s returned block %s instead of %s", peerAddr, parent.Hash(), missing)
+            return
+        }
+        
+        err = s.blockchain.AddBlock(parent)
+        if !errors.Is(err, blockchain.ErrOrphanBlock) {
+            if err != nil && !errors.Is(err, blockchain.ErrBlockKnown) {
+                s.logger.Warn("Failed to add parent block %s: %v", missing, err)
+            }
+            return
+        }
+        
+        // The parent is an orphan too, keep walking back
+        missing = s.blockchain.GetMissingParent(missing)
+    }
+}
+
+// AddPeer adds a peer, or marks a known one as seen, and reports whether the
+// peer is known. New peers are refused once Network.MaxPeers are known.
+func (s *Server) AddPeer(address string) bool {
     s.peersMu.Lock()
     defer s.peersMu.Unlock()
     
+    if peer, ok := s.peers[address]; ok {
+        peer.LastSeen = time.Now()
+        return true
+    }
+    
+    if len(s.peers) >= s.config.Network.MaxPeers {
+        s.logger.Debug("Peer limit of %d reached, not adding %s", s.config.Network.MaxPeers, address)
+        return false
+    }
+    
     s.peers[address] = &Peer{
         Address:  address,
         LastSeen: time.Now(),
     }
     
     s.logger.Info("Added peer: %s", address)
+    return true
+}
+
+// UpdatePeerChainState records the chain height and work reported by a peer
+func (s *Server) UpdatePeerChainState(address string, height uint64, work *big.Int) {
+    s.peersMu.Lock()
+    defer s.peersMu.Unlock()
+    
+    if peer, ok := s.peers[address]; ok {
+        peer.Height = height
+        peer.ChainWork = work.Text(16)
+        peer.LastSeen = time.Now()
+    }
 }
 
 // RemovePeer removes a peer
@@ -0,0 +1,384 @@
+package network
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math/big"
+    "sort"
+    "sync"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+const (
+    // syncInterval is how often peer heights are compared with the local chain
+    syncInterval = 30 * time.Second
+    
+    // maxSyncRetries is how many times a download is retried before switching peers
+    maxSyncRetries = 3
+    
+    // syncRetryDelay is the base delay between download retries
+    syncRetryDelay = 5 * time.Second
+)
+
+// SyncStatus describes the progress of chain synchronization
+type SyncStatus struct {
+    Syncing       bool      `json:"syncing"`
+    Phase         string    `json:"phase,omitempty"`
+    Peer          string    `json:"peer,omitempty"`
+    StartHeight   uint64    `json:"start_height"`
+    CurrentHeight uint64    `json:"current_height"`
+    TargetHeight  uint64    `json:"target_height"`
+    LastSync      time.Time `json:"last_sync,omitempty"`
+    LastError     string    `json:"last_error,omitempty"`
+}
+
+// syncClient is the part of the peer client that synchronization uses
+type syncClient interface {
+    GetPeerChainState(peerAddr string) (*PeerChainState, error)
+    GetHeaders(peerAddr string, from uint64, count int) ([]blockchain.BlockHeader, error)
+    GetBlocks(ctx context.Context, peerAddr string, from uint64, count int) ([]*blockchain.Block, error)
+}
+
+// SyncManager keeps the local chain in step with the best known peer
+type SyncManager struct {
+    server *Server
+    client syncClient
+    logger *utils.Logger
+    
+    mu     sync.RWMutex
+    status SyncStatus
+}
+
+// NewSyncManager creates a new sync manager and registers it with the server
+func NewSyncManager(server *Server, logger *utils.Logger) *SyncManager {
+    sm := &SyncManager{
+        server: server,
+        client: server.client,
+        logger: logger,
+    }
+    
+    server.syncManager = sm
+    return sm
+}
+
+// Start starts the background synchronization loop
+func (sm *SyncManager) Start(ctx context.Context) {
+    sm.logger.Info("Sync manager started")
+    
+    // Give discovery a moment to find peers before the first round
+    select {
+    case <-ctx.Done():
+        return
+    case <-time.After(5 * time.Second):
+    }
+    
+    sm.syncRound(ctx)
+    
+    ticker := time.NewTicker(syncInterval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-ctx.Done():
+            sm.logger.Info("Sync manager stopped")
+            return
+        case <-ticker.C:
+            sm.syncRound(ctx)
+        }
+    }
+}
+
+// Status returns the current synchronization status
+func (sm *SyncManager) Status() SyncStatus {
+    sm.mu.RLock()
+    defer sm.mu.RUnlock()
+    
+    return sm.status
+}
+
+// syncCandidate is a peer whose chain has more work than the local chain
+type syncCandidate struct {
+    address string
+    height  uint64
+    work    *big.Int
+}
+
+// syncRound compares chain work with all peers and syncs from the best ones
+func (sm *SyncManager) syncRound(ctx context.Context) {
+    localWork := sm.server.blockchain.GetChainWork()
+    
+    candidates := sm.peersAhead(localWork)
+    if len(candidates) == 0 {
+        return
+    }
+    
+    // Try the peer with the most work first and switch peers when a download fails
+    for i, peer := range candidates {
+        if ctx.Err() != nil {
+            return
+        }
+        
+        // Bodies can come from any peer that has the whole range
+        bodyPeers := []string{peer.address}
+        for j, other := range candidates {
+            if j != i && other.height >= peer.height {
+                bodyPeers = append(bodyPeers, other.address)
+            }
+        }
+        
+        err := sm.syncWithRetries(ctx, peer.address, peer.height, bodyPeers)
+        if err == nil {
+            return
+        }
+        
+        sm.logger.Warn("Sync with %s failed, switching peer: %v", peer.address, err)
+    }
+}
+
+// peersAhead queries peer chain state and returns the peers with more work than
+// the local chain, most work first
+func (sm *SyncManager) peersAhead(localWork *big.Int) []*syncCandidate {
+    peers := sm.server.GetPeers()
+    
+    var (
+        wg     sync.WaitGroup
+        mu     sync.Mutex
+        result []*syncCandidate
+    )
+    
+    for _, peer := range peers {
+        wg.Add(1)
+        go func(address string) {
+            defer wg.Done()
+            
+            state, err := sm.client.GetPeerChainState(address)
+            if err != nil {
+                sm.logger.Debug("Failed to get chain state of %s: %v", address, err)
+                return
+            }
+            
+            sm.server.UpdatePeerChainState(address, state.Height, state.ChainWork)
+            sm.server.blockchain.AddPeerTime(address, state.Time)
+            
+            if state.ChainWork.Cmp(localWork) > 0 {
+                mu.Lock()
+                result = append(result, &syncCandidate{address: address, height: state.Height, work: state.ChainWork})
+                mu.Unlock()
+            }
+        }(peer.Address)
+    }
+    
+    wg.Wait()
+    
+    sort.Slice(result, func(i, j int) bool {
+        return result[i].work.Cmp(result[j].work) > 0
+    })
+    
+    return result
+}
+
+// syncWithRetries syncs from a single peer, retrying failed downloads
+func (sm *SyncManager) syncWithRetries(ctx context.Context, peerAddr string, target uint64, bodyPeers []string) error {
+    var err error
+    
+    for attempt := 1; attempt <= maxSyncRetries; attempt++ {
+        if err = sm.syncFromPeer(ctx, peerAddr, target, bodyPeers); err == nil {
+            return nil
+        }
+        
+        sm.logger.Warn("Sync attempt %d/%d with %s failed: %v", attempt, maxSyncRetries, peerAddr, err)
+        
+        if attempt < maxSyncRetries {
+            select {
+            case <-ctx.Done():
+                return ctx.Err()
+            case <-time.After(syncRetryDelay * time.Duration(attempt)):
+            }
+        }
+    }
+    
+    return err
+}
+
+// syncFromPeer syncs headers first from a peer, then downloads the block bodies
+// in parallel from bodyPeers and adds them to the chain
+func (sm *SyncManager) syncFromPeer(ctx context.Context, peerAddr string, target uint64, bodyPeers []string) error {
+    bc := sm.server.blockchain
+    
+    forkHeight, err := sm.findForkPoint(peerAddr, target)
+    if err != nil {
+        sm.finish(err)
+        return err
+    }
+    
+    sm.mu.Lock()
+    sm.status.Syncing = true
+    sm.status.Phase = "headers"
+    sm.status.Peer = peerAddr
+    sm.status.StartHeight = bc.GetHeight()
+    sm.status.CurrentHeight = forkHeight
+    sm.status.TargetHeight = target
+    sm.mu.Unlock()
+    
+    headers, err := sm.downloadHeaders(peerAddr, forkHeight, target)
+    if err != nil {
+        sm.finish(err)
+        return err
+    }
+    
+    if len(headers) == 0 {
+        sm.finish(nil)
+        return nil
+    }
+    
+    sm.mu.Lock()
+    sm.status.Phase = "blocks"
+    sm.status.TargetHeight = headers[len(headers)-1].Height
+    sm.mu.Unlock()
+    
+    sm.logger.Info("Downloading blocks %d to %d from %d peers", forkHeight+1, headers[len(headers)-1].Height, len(bodyPeers))
+    
+    fetcher := newBlockFetcher(sm.client, sm.logger)
+    err = fetcher.fetch(ctx, bodyPeers, headers, func(block *blockchain.Block) error {
+        err := bc.AddBlock(block)
+        if err != nil && !errors.Is(err, blockchain.ErrBlockKnown) {
+            return fmt.Errorf("failed to add block %d: %w", block.Header.Height, err)
+        }
+        
+        sm.mu.Lock()
+        sm.status.CurrentHeight = block.Header.Height
+        sm.mu.Unlock()
+        
+        return nil
+    })
+    
+    sm.finish(err)
+    
+    if err == nil {
+        sm.logger.Info("Sync with %s complete at height %d", peerAddr, bc.GetHeight())
+    }
+    
+    return err
+}
+
+// downloadHeaders fetches and validates the peer's headers above forkHeight up to target
+func (sm *SyncManager) downloadHeaders(peerAddr string, forkHeight, target uint64) ([]blockchain.BlockHeader, error) {
+    var headers []blockchain.BlockHeader
+    
+    for from := forkHeight + 1; from <= target; {
+        batch, err := sm.client.GetHeaders(peerAddr, from, maxHeadersPerRequest)
+        if err != nil {
+            return nil, fmt.Errorf("failed to get headers from %d: %w", from, err)
+        }
+        
+        if len(batch) == 0 {
+            break
+        }
+        
+        headers = append(headers, batch...)
+        if err := sm.server.blockchain.CheckHeaders(headers, len(headers)-len(batch)); err != nil {
+            return nil, fmt.Errorf("invalid headers from %s: %w", peerAddr, err)
+        }
+        
+        parent := &headers[len(headers)-1]
+        from = parent.Height + 1
+        
+        sm.mu.Lock()
+        sm.status.CurrentHeight = parent.Height
+        sm.mu.Unlock()
+    }
+    
+    return headers, nil
+}
+
+// findForkPoint returns the highest local main chain height that the peer's
+// chain shares. It steps back exponentially from the tip comparing single
+// headers, then scans the headers between the match and the last mismatch.
+func (sm *SyncManager) findForkPoint(peerAddr string, peerHeight uint64) (uint64, error) {
+    bc := sm.server.blockchain
+    height := bc.GetHeight()
+    
+    // A peer with more work can still have a shorter chain
+    if peerHeight < height {
+        height = peerHeight
+    }
+    
+    mismatch := height + 1
+    for step := uint64(1); ; step *= 2 {
+        same, err := sm.sameHeaders(peerAddr, height, 1)
+        if err != nil {
+            return 0, err
+        }
+        if same == 1 {
+            break
+        }
+        
+        if height == 0 {
+            return 0, fmt.Errorf("peer has a different genesis block")
+        }
+        mismatch = height
+        
+        if height < step {
+            height = 0
+        } else {
+            height -= step
+        }
+    }
+    
+    for height+1 < mismatch {
+        count := mismatch - height - 1
+        if count > maxHeadersPerRequest {
+            count = maxHeadersPerRequest
+        }
+        
+        same, err := sm.sameHeaders(peerAddr, height+1, int(count))
+        if err != nil {
+            return 0, err
+        }
+        height += uint64(same)
+        
+        if same < int(count) {
+            break
+        }
+    }
+    
+    return height, nil
+}
+
+// sameHeaders returns how many of the peer's headers from a height, up to
+// count, match the local main chain before the first difference
+func (sm *SyncManager) sameHeaders(peerAddr string, from uint64, count int) (int, error) {
+    remote, err := sm.client.GetHeaders(peerAddr, from, count)
+    if err != nil {
+        return 0, fmt.Errorf("failed to get headers from %d: %w", from, err)
+    }
+    local := sm.server.blockchain.GetHeaders(from, count)
+    
+    for i := range remote {
+        if i >= len(local) || remote[i].Hash() != local[i].Hash() {
+            return i, nil
+        }
+    }
+    
+    return len(remote), nil
+}
+
+// finish records the end of a sync attempt
+func (sm *SyncManager) finish(err error) {
+    sm.mu.Lock()
+    defer sm.mu.Unlock()
+    
+    sm.status.Syncing = false
+    sm.status.Phase = ""
+    if err != nil {
+        sm.status.LastError = err.Error()
+        return
+    }
+    
+    sm.status.LastError = ""
+    sm.status.LastSync = time.Now()
+}
\ No newline at end of file
@@ -0,0 +1,280 @@
+package network
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "sync"
+    "testing"
+    "time"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+    "github.com/CertificationAgencyBlockchain/node/chainparams"
+    "github.com/CertificationAgencyBlockchain/node/config"
+    "github.com/CertificationAgencyBlockchain/node/crypto"
+    "github.com/CertificationAgencyBlockchain/node/storage"
+    "github.com/CertificationAgencyBlockchain/node/utils"
+)
+
+// testLogger returns a logger that discards its output
+func testLogger() *utils.Logger {
+    logger := utils.NewLogger(false)
+    logger.SetOutput(io.Discard)
+    return logger
+}
+
+// newTestChain creates a regtest chain on a temporary database
+func newTestChain(t *testing.T) *blockchain.Blockchain {
+    t.Helper()
+    
+    params := chainparams.RegTest
+    cfg := &config.Config{
+        Network:    config.NetworkConfig{Chain: params.Name, MaxPeers: 8},
+        Blockchain: config.BlockchainConfig{MaxBlockSize: 1 << 20, MagicValue: params.MagicValue},
+        Mining: config.MiningConfig{
+            Engine:            "pow",
+            Threads:           1,
+            InitialDifficulty: params.InitialDifficulty,
+            DifficultyAdjust:  2016,
+            TargetBlockTime:   10 * time.Minute,
+            MaxTransPerBlock:  1000,
+        },
+        Mempool:  config.MempoolConfig{MaxSize: 1 << 20},
+        Security: config.SecurityConfig{MaxInquiryAge: 24 * time.Hour},
+    }
+    
+    db, err := storage.NewDatabase(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { db.Close() })
+    
+    bc, err := blockchain.NewBlockchain(cfg, db, testLogger())
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    return bc
+}
+
+// generate mines n blocks on the chain. With a certification the first block
+// differs from the blocks any other chain mines at the same time.
+func generate(t *testing.T, bc *blockchain.Blockchain, n int, certify bool) {
+    t.Helper()
+    
+    if certify {
+        key, _, err := crypto.GenerateRSAKeyPair(1024)
+        if err != nil {
+            t.Fatal(err)
+        }
+        publicKey, err := crypto.PublicKeyToPEM(&key.PublicKey)
+        if err != nil {
+            t.Fatal(err)
+        }
+        
+        tx := blockchain.NewTransaction(publicKey, "Jane", "Doe", "inquiry-1", time.Now().Add(-time.Hour), "")
+        if tx.Signature, err = crypto.SignMessage(key, tx.GetSignableMessage()); err != nil {
+            t.Fatal(err)
+        }
+        if err := bc.AddTransaction(tx); err != nil {
+            t.Fatal(err)
+        }
+    }
+    
+    if _, err := bc.GenerateBlocks(context.Background(), n); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// copyBlocks adds the main chain blocks of src from a height on to dst
+func copyBlocks(t *testing.T, dst, src *blockchain.Blockchain, from uint64) {
+    t.Helper()
+    
+    for _, block := range src.GetBlocks(from, int(src.GetHeight()-from+1)) {
+        if err := dst.AddBlock(block); err != nil && !errors.Is(err, blockchain.ErrBlockKnown) {
+            t.Fatal(err)
+        }
+    }
+}
+
+// forkedChains returns a local chain and a peer chain that share the first
+// shared blocks and then mine the given numbers of blocks each
+func forkedChains(t *testing.T, shared, localExtra, peerExtra int) (local, peer *blockchain.Blockchain) {
+    t.Helper()
+    
+    local, peer = newTestChain(t), newTestChain(t)
+    
+    if shared > 0 {
+        generate(t, peer, shared, false)
+        copyBlocks(t, local, peer, 1)
+    }
+    if localExtra > 0 {
+        generate(t, local, localExtra, false)
+    }
+    if peerExtra > 0 {
+        generate(t, peer, peerExtra, true)
+    }
+    
+    return local, peer
+}
+
+// stubPeer is a peer served by the stub client
+type stubPeer struct {
+    chain   *blockchain.Blockchain
+    err     error
+    stall   bool
+    shifted bool
+    corrupt bool
+}
+
+// stubClient serves peer requests from local chains and counts block requests
+type stubClient struct {
+    peers map[string]*stubPeer
+    
+    mu    sync.Mutex
+    calls map[string]int
+}
+
+func newStubClient(peers map[string]*stubPeer) *stubClient {
+    return &stubClient{peers: peers, calls: make(map[string]int)}
+}
+
+func (c *stubClient) peer(address string) (*stubPeer, error) {
+    p, ok := c.peers[address]
+    if !ok {
+        return nil, fmt.Errorf("unknown peer %s", address)
+    }
+    return p, nil
+}
+
+func (c *stubClient) GetPeerChainState(peerAddr string) (*PeerChainState, error) {
+    p, err := c.peer(peerAddr)
+    if err != nil {
+        return nil, err
+    }
+    return &PeerChainState{Height: p.chain.GetHeight(), ChainWork: p.chain.GetChainWork(), Time: time.Now()}, nil
+}
+
+func (c *stubClient) GetHeaders(peerAddr string, from uint64, count int) ([]blockchain.BlockHeader, error) {
+    p, err := c.peer(peerAddr)
+    if err != nil {
+        return nil, err
+    }
+    
+    headers := p.chain.GetHeaders(from, count)
+    if p.corrupt && len(headers) > 1 {
+        headers[0].Nonce++
+    }
+    return headers, nil
+}
+
+func (c *stubClient) GetBlocks(ctx context.Context, peerAddr string, from uint64, count int) ([]*blockchain.Block, error) {
+    p, err := c.peer(peerAddr)
+    if err != nil {
+        return nil, err
+    }
+    
+    c.mu.Lock()
+    c.calls[peerAddr]++
+    c.mu.Unlock()
+    
+    switch {
+    case p.err != nil:
+        return nil, p.err
+    case p.stall:
+        <-ctx.Done()
+        return nil, ctx.Err()
+    case p.shifted:
+        from++
+    }
+    return p.chain.GetBlocks(from, count), nil
+}
+
+// callCount returns the number of block requests sent to a peer
+func (c *stubClient) callCount(address string) int {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.calls[address]
+}
+
+// newTestSyncManager creates a sync manager for the local chain using the stub client
+func newTestSyncManager(local *blockchain.Blockchain, client *stubClient) *SyncManager {
+    return &SyncManager{
+        server: &Server{blockchain: local},
+        client: client,
+        logger: testLogger(),
+    }
+}
+
+func TestFindForkPoint(t *testing.T) {
+    tests := []struct {
+        name                          string
+        shared, localExtra, peerExtra int
+        want                          uint64
+    }{
+        {"peer extends the local tip", 5, 0, 3, 5},
+        {"peer is behind on the same chain", 3, 4, 0, 3},
+        {"fork one block below the tip", 6, 1, 3, 6},
+        {"fork several steps below the tip", 6, 9, 12, 6},
+        {"fork right after genesis", 0, 20, 22, 0},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            local, peer := forkedChains(t, tt.shared, tt.localExtra, tt.peerExtra)
+            sm := newTestSyncManager(local, newStubClient(map[string]*stubPeer{"peer": {chain: peer}}))
+            
+            got, err := sm.findForkPoint("peer", peer.GetHeight())
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Fatalf("fork point %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSyncFromPeer(t *testing.T) {
+    tests := []struct {
+        name    string
+        peer    stubPeer
+        wantErr bool
+    }{
+        {"reorganizes to the peer's chain", stubPeer{}, false},
+        {"rejects invalid headers", stubPeer{corrupt: true}, true},
+        {"fails when no peer serves the bodies", stubPeer{err: errors.New("connection refused")}, true},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            local, peer := forkedChains(t, 3, 2, 6)
+            localTip := local.GetLatestBlock().Hash()
+            
+            tt.peer.chain = peer
+            sm := newTestSyncManager(local, newStubClient(map[string]*stubPeer{"peer": &tt.peer}))
+            
+            err := sm.syncFromPeer(context.Background(), "peer", peer.GetHeight(), []string{"peer"})
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("got error %v, want an error %v", err, tt.wantErr)
+            }
+            
+            status := sm.Status()
+            if status.Syncing {
+                t.Fatal("status still reports syncing")
+            }
+            
+            want := peer.GetLatestBlock().Hash()
+            if tt.wantErr {
+                want = localTip
+                if status.LastError == "" {
+                    t.Fatal("status does not report the error")
+                }
+            }
+            if tip := local.GetLatestBlock().Hash(); tip != want {
+                t.Fatalf("local tip %s, want %s", tip, want)
+            }
+        })
+    }
+}
\ No newline at end of file
@@ -0,0 +1,65 @@
+package network
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+    
+    "github.com/CertificationAgencyBlockchain/node/blockchain"
+)
+
+const (
+    // binaryContentType is the media type of the canonical binary encoding peers exchange
+    binaryContentType = blockchain.BinaryContentType
+    
+    // maxMessageSize is the largest binary message other than blocks accepted from a peer
+    maxMessageSize = 32 << 20
+    
+    // blockListOverhead is the length prefix of an encoded list of blocks
+    blockListOverhead = 4
+)
+
+// encodeMessage frames a binary payload with the network magic value and its length
+func encodeMessage(magic uint32, payload []byte) []byte {
+    var buf bytes.Buffer
+    blockchain.WriteFrame(&buf, magic, payload)
+    
+    return buf.Bytes()
+}
+
+// decodeMessage reads a framed binary message of at most maxSize bytes and returns
+// its payload. Messages of another network, oversized messages and trailing data
+// are rejected.
+func decodeMessage(magic uint32, r io.Reader, maxSize int) ([]byte, error) {
+    payload, err := blockchain.ReadFrame(r, magic, maxSize)
+    if err != nil {
+        if err == io.EOF {
+            return nil, fmt.Errorf("empty message")
+        }
+        return nil, err
+    }
+    
+    if n, _ := r.Read(make([]byte, 1)); n != 0 {
+        return nil, fmt.Errorf("trailing data after message")
+    }
+    
+    return payload, nil
+}
+
+// wantsBinary checks if a request asks for the binary encoding instead of JSON
+func wantsBinary(r *http.Request) bool {
+    return strings.Contains(r.Header.Get("Accept"), binaryContentType)
+}
+
+// writeMessage answers a peer with a framed binary payload
+func (s *Server) writeMessage(w http.ResponseWriter, payload []byte, err error) {
+    if err != nil {
+        http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+        return
+    }
+    
+    w.Header().Set("Content-Type", binaryContentType)
+    w.Write(encodeMessage(s.config.Blockchain.MagicValue, payload))
+}
\ No newline at end of file
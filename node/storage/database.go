@@ -3,6 +3,8 @@ package storage
 import (
     "encoding/json"
     "fmt"
+    "math/big"
+    "strconv"
     "time"
     
     "github.com/dgraph-io/badger/v4"
@@ -42,30 +44,9 @@ func (d *Database) Close() error {
     return d.db.Close()
 }
 
-// SaveBlock saves a block to the database
-func (d *Database) SaveBlock(block interface{}) error {
-    // Convert block to JSON
-    data, err := json.Marshal(block)
-    if err != nil {
-        return fmt.Errorf("failed to marshal block: %w", err)
-    }
-    
-    // Get block height and hash from interface
-    var height uint64
-    var hash string
-    
-    if b, ok := block.(interface {
-        Hash() string
-        Header struct {
-            Height uint64
-        }
-    }); ok {
-        height = b.Header.Height
-        hash = b.Hash()
-    } else {
-        return fmt.Errorf("invalid block type")
-    }
-    
+// SaveBlock saves an encoded block and its cumulative chain work to the database
+// and makes it the chain tip
+func (d *Database) SaveBlock(hash string, height uint64, data []byte, chainWork *big.Int) error {
     return d.db.Update(func(txn *badger.Txn) error {
         // Save block by height
         heightKey := fmt.Sprintf("block:height:%d", height)
@@ -79,6 +60,12 @@ func (d *Database) SaveBlock(block interface{}) error {
             return err
         }
         
+        // Save cumulative chain work
+        workKey := fmt.Sprintf("chainwork:%s", hash)
+        if err := txn.Set([]byte(workKey), chainWork.Bytes()); err != nil {
+            return err
+        }
+        
         // Update latest block height
         if err := txn.Set([]byte("blockchain:height"), []byte(fmt.Sprintf("%d", height))); err != nil {
             return err
@@ -88,6 +75,93 @@ func (d *Database) SaveBlock(block interface{}) error {
     })
 }
 
+// StoreBlock saves an encoded side chain block and its cumulative chain work by hash only
+func (d *Database) StoreBlock(hash string, data []byte, chainWork *big.Int) error {
+    return d.db.Update(func(txn *badger.Txn) error {
+        hashKey := fmt.Sprintf("block:hash:%s", hash)
+        if err := txn.Set([]byte(hashKey), data); err != nil {
+            return err
+        }
+        
+        workKey := fmt.Sprintf("chainwork:%s", hash)
+        return txn.Set([]byte(workKey), chainWork.Bytes())
+    })
+}
+
+// GetChainWork gets the cumulative chain work stored for a block hash
+func (d *Database) GetChainWork(hash string) (*big.Int, error) {
+    var work *big.Int
+    
+    err := d.db.View(func(txn *badger.Txn) error {
+        key := fmt.Sprintf("chainwork:%s", hash)
+        item, err := txn.Get([]byte(key))
+        if err != nil {
+            return err
+        }
+        
+        return item.Value(func(val []byte) error {
+            work = new(big.Int).SetBytes(val)
+            return nil
+        })
+    })
+    
+    if err != nil {
+        if err == badger.ErrKeyNotFound {
+            return nil, nil
+        }
+        return nil, err
+    }
+    
+    return work, nil
+}
+
+// DisconnectBlock removes the chain tip at the given height from the main chain.
+// The block stays available by hash so it can be reconnected by a later reorg.
+func (d *Database) DisconnectBlock(height uint64) error {
+    return d.db.Update(func(txn *badger.Txn) error {
+        heightKey := fmt.Sprintf("block:height:%d", height)
+        if err := txn.Delete([]byte(heightKey)); err != nil {
+            return err
+        }
+        
+        if height == 0 {
+            return txn.Delete([]byte("blockchain:height"))
+        }
+        
+        return txn.Set([]byte("blockchain:height"), []byte(fmt.Sprintf("%d", height-1)))
+    })
+}
+
+// GetChainHeight returns the height of the stored chain tip
+func (d *Database) GetChainHeight() (uint64, bool, error) {
+    var height uint64
+    
+    err := d.db.View(func(txn *badger.Txn) error {
+        item, err := txn.Get([]byte("blockchain:height"))
+        if err != nil {
+            return err
+        }
+        
+        return item.Value(func(val []byte) error {
+            parsed, err := strconv.ParseUint(string(val), 10, 64)
+            if err != nil {
+                return fmt.Errorf("invalid stored height %q: %w", val, err)
+            }
+            height = parsed
+            return nil
+        })
+    })
+    
+    if err != nil {
+        if err == badger.ErrKeyNotFound {
+            return 0, false, nil
+        }
+        return 0, false, err
+    }
+    
+    return height, true, nil
+}
+
 // GetBlock gets a block by height
 func (d *Database) GetBlock(height uint64) ([]byte, error) {
     var data []byte
@@ -132,10 +206,74 @@ func (d *Database) GetBlockByHash(hash string) ([]byte, error) {
     return data, nil
 }
 
-// GetAllBlocks returns all blocks from the database
-func (d *Database) GetAllBlocks() ([]interface{}, error) {
-    // This is a simplified version - in production, you'd need proper block deserialization
-    return nil, nil
+// GetAllBlocks returns the encoded blocks of the stored chain ordered by height
+func (d *Database) GetAllBlocks() ([][]byte, error) {
+    height, ok, err := d.GetChainHeight()
+    if err != nil {
+        return nil, err
+    }
+    
+    if !ok {
+        return nil, nil
+    }
+    
+    blocks := make([][]byte, 0, height+1)
+    
+    err = d.db.View(func(txn *badger.Txn) error {
+        for h := uint64(0); h <= height; h++ {
+            key := fmt.Sprintf("block:height:%d", h)
+            item, err := txn.Get([]byte(key))
+            if err != nil {
+                if err == badger.ErrKeyNotFound {
+                    return fmt.Errorf("missing block at height %d", h)
+                }
+                return err
+            }
+            
+            data, err := item.ValueCopy(nil)
+            if err != nil {
+                return err
+            }
+            
+            blocks = append(blocks, data)
+        }
+        
+        return nil
+    })
+    
+    if err != nil {
+        return nil, err
+    }
+    
+    return blocks, nil
+}
+
+// GetStoredBlocks returns every encoded block stored by hash, including side chains
+func (d *Database) GetStoredBlocks() ([][]byte, error) {
+    var blocks [][]byte
+    
+    err := d.db.View(func(txn *badger.Txn) error {
+        opts := badger.DefaultIteratorOptions
+        it := txn.NewIterator(opts)
+        defer it.Close()
+        
+        prefix := []byte("block:hash:")
+        for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+            data, err := it.Item().ValueCopy(nil)
+            if err != nil {
+                return err
+            }
+            blocks = append(blocks, data)
+        }
+        
+        return nil
+    })
+    
+    if err != nil {
+        return nil, err
+    }
+    
+    return blocks, nil
 }
 
 // SaveCertification saves a certification to the database
@@ -168,6 +306,45 @@ func (d *Database) SaveCertification(cert *Certification) error {
     })
 }
 
+// DeleteCertification removes the index entries of a certification.
+// Entries that were since overwritten by a certification from another block are kept.
+func (d *Database) DeleteCertification(cert *Certification) error {
+    keys := []string{
+        fmt.Sprintf("cert:pk:%s", cert.PublicKey),
+        fmt.Sprintf("cert:id:%s:%s", cert.Name, cert.Surname),
+        fmt.Sprintf("cert:inq:%s", cert.InquiryID),
+    }
+    
+    return d.db.Update(func(txn *badger.Txn) error {
+        for _, key := range keys {
+            item, err := txn.Get([]byte(key))
+            if err == badger.ErrKeyNotFound {
+                continue
+            }
+            if err != nil {
+                return err
+            }
+            
+            var stored Certification
+            if err := item.Value(func(val []byte) error {
+                return json.Unmarshal(val, &stored)
+            }); err != nil {
+                return err
+            }
+            
+            if stored.BlockHash != cert.BlockHash {
+                continue
+            }
+            
+            if err := txn.Delete([]byte(key)); err != nil {
+                return err
+            }
+        }
+        
+        return nil
+    })
+}
+
 // GetCertificationByPublicKey gets a certification by public key
 func (d *Database) GetCertificationByPublicKey(publicKey string) (*Certification, error) {
     var cert Certification
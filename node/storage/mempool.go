@@ -0,0 +1,60 @@
+package storage
+
+import (
+    "fmt"
+    
+    "github.com/dgraph-io/badger/v4"
+)
+
+// mempoolPrefix prefixes the keys of pending transactions
+const mempoolPrefix = "mempool:"
+
+// SavePoolEntry saves an encoded pending transaction by ID
+func (d *Database) SavePoolEntry(id string, data []byte) error {
+    return d.db.Update(func(txn *badger.Txn) error {
+        key := fmt.Sprintf("%s%s", mempoolPrefix, id)
+        return txn.Set([]byte(key), data)
+    })
+}
+
+// DeletePoolEntries removes pending transactions by ID
+func (d *Database) DeletePoolEntries(ids []string) error {
+    return d.db.Update(func(txn *badger.Txn) error {
+        for _, id := range ids {
+            key := fmt.Sprintf("%s%s", mempoolPrefix, id)
+            if err := txn.Delete([]byte(key)); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// GetPoolEntries returns every encoded pending transaction by ID
+func (d *Database) GetPoolEntries() (map[string][]byte, error) {
+    entries := make(map[string][]byte)
+    
+    err := d.db.View(func(txn *badger.Txn) error {
+        opts := badger.DefaultIteratorOptions
+        it := txn.NewIterator(opts)
+        defer it.Close()
+        
+        prefix := []byte(mempoolPrefix)
+        for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+            data, err := it.Item().ValueCopy(nil)
+            if err != nil {
+                return err
+            }
+            id := string(it.Item().Key()[len(prefix):])
+            entries[id] = data
+        }
+        
+        return nil
+    })
+    
+    if err != nil {
+        return nil, err
+    }
+    
+    return entries, nil
+}
\ No newline at end of file
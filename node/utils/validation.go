@@ -0,0 +1,23 @@
+package utils
+
+import (
+    "fmt"
+    "time"
+)
+
+// ValidateAge checks that t is at most maxAge before ref and at most maxFuture after it
+func ValidateAge(t, ref time.Time, maxAge, maxFuture time.Duration) error {
+    if t.IsZero() {
+        return fmt.Errorf("time is not set")
+    }
+    
+    if ref.Sub(t) > maxAge {
+        return fmt.Errorf("time %s is more than %s before %s", t.Format(time.RFC3339), maxAge, ref.Format(time.RFC3339))
+    }
+    
+    if t.Sub(ref) > maxFuture {
+        return fmt.Errorf("time %s is more than %s after %s", t.Format(time.RFC3339), maxFuture, ref.Format(time.RFC3339))
+    }
+    
+    return nil
+}
\ No newline at end of file